@@ -1,22 +1,27 @@
 package application
 
 import (
-	"bytes"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"net/http"
-	"os"
-	"strings"
+	"log/slog"
+	"net/url"
+	"strconv"
 	"time"
 
 	ajg "github.com/ajg/form"
 
 	"github.com/Danny-Dasilva/CycleTLS/cycletls"
-	"github.com/jackc/pgx/v4"
+	"github.com/rodatboat/go-vocab/db"
+	"github.com/rodatboat/go-vocab/journal"
+	"github.com/rodatboat/go-vocab/llm"
+	"github.com/rodatboat/go-vocab/media"
 	"github.com/rodatboat/go-vocab/model"
+	"github.com/rodatboat/go-vocab/prompt"
 	"github.com/rodatboat/go-vocab/utils"
 )
 
@@ -32,11 +37,32 @@ type RunParams struct {
 	Guid       string
 
 	Ja3 string
+
+	// Selects the storage backend by scheme, e.g. postgres://, sqlite://, memory://.
+	// Defaults to the postgres database described by RunDBConfig.
+	StoreDSN string
+
+	// When set, I-type choice images are downloaded into this directory.
+	MediaDir string
+
+	// Zero values fall back to DefaultDBConfig and DEFAULT_USER_AGENT.
+	DBConfig  RunDBConfig
+	UserAgent string
+
+	LLM LLMConfig
+
+	// CASSETTE_RECORD saves every request and response to the Cassette file,
+	// CASSETTE_REPLAY serves them from it instead of vocabulary.com.
+	Cassette     string
+	CassetteMode string
+
+	// When set, every session event is appended to this JSONL file.
+	Journal string
 }
 
 type RunContext struct {
+	SessionId                   string
 	ListId                      int
-	OllamaQuery                 string
 	CurrentQuestion             *model.Question
 	PointsEarned                int
 	Secret                      string
@@ -46,23 +72,66 @@ type RunContext struct {
 	Cookies []cycletls.Cookie
 }
 
+const DEFAULT_USER_AGENT = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/132.0.0.0 Safari/537.36 OPR/117.0.0.0"
+const DEFAULT_OLLAMA_URL = "http://localhost:11434/api/generate"
+
+// Pause between the requests of a practice session.
+const PRACTICE_DELAY = 3 * time.Second
+
 type RunDBConfig struct {
-	dbname   string
-	host     string
-	port     string
-	user     string
-	password string
+	DBName   string
+	Host     string
+	Port     string
+	User     string
+	Password string
+}
+
+func DefaultDBConfig() RunDBConfig {
+	return RunDBConfig{
+		DBName:   "vocabularycom",
+		Host:     "localhost",
+		Port:     "5432",
+		User:     "postgres",
+		Password: "password",
+	}
+}
+
+func (c RunDBConfig) DSN() string {
+	credentials := url.UserPassword(c.User, c.Password)
+	return fmt.Sprintf("postgresql://%s@%s:%s/%s?sslmode=disable",
+		credentials.String(), c.Host, c.Port, c.DBName)
 }
 
 type Runner struct {
 	DBConfig      RunDBConfig
-	Conn          *pgx.Conn
+	Store         db.QuestionStore
+	Media         *media.Cache
+	LLM           LLMProvider
+	Transport     Transport
+	prompts       *prompt.Templates
+	answerSchema  json.RawMessage
+	llmModel      string
+	explain       bool
+	delay         time.Duration
+	log           *slog.Logger
+	journal       *journal.Writer
 	ctx           *RunContext
-	client        cycletls.CycleTLS
 	clientOptions cycletls.Options
 }
 
-func New(params RunParams) *Runner {
+// New opens what the runner uses in turn. When a step fails, whatever was
+// already opened is closed again, last first.
+func New(params RunParams) (_ *Runner, err error) {
+	var closers []func() error
+	defer func() {
+		if err == nil {
+			return
+		}
+		for i := len(closers) - 1; i >= 0; i-- {
+			closers[i]()
+		}
+	}()
+
 	cookies := []cycletls.Cookie{
 		{Name: "AWSALB", Value: params.AlbCookie},
 		{Name: "JSESSIONID", Value: params.JSessionId},
@@ -71,17 +140,33 @@ func New(params RunParams) *Runner {
 
 	cookieHeader, err := utils.GetCookiesString(cookies)
 	if err != nil {
-		fmt.Println("Error creating cookie header string", err)
-		panic(err)
+		return nil, fmt.Errorf("creating cookie header: %w", err)
 	}
 
-	rawQuery, err := os.ReadFile("./db/ai_query.json")
+	prompts, err := prompt.Load(params.LLM.PromptDir)
+	if err != nil {
+		return nil, err
+	}
+	answerSchema, err := loadAnswerSchema(params.LLM.SchemaFile)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := NewLLMProvider(params.LLM)
 	if err != nil {
-		fmt.Println("Error reading ai_query.json:", err)
-		panic(err)
+		return nil, err
+	}
+	transport, err := NewTransport(params.CassetteMode, params.Cassette)
+	if err != nil {
+		return nil, err
+	}
+	if closer, ok := transport.(io.Closer); ok {
+		closers = append(closers, closer.Close)
 	}
 
-	userAgent := "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/132.0.0.0 Safari/537.36 OPR/117.0.0.0"
+	userAgent := params.UserAgent
+	if userAgent == "" {
+		userAgent = DEFAULT_USER_AGENT
+	}
 	options := cycletls.Options{
 		Ja3: params.Ja3,
 		Headers: map[string]string{
@@ -94,53 +179,88 @@ func New(params RunParams) *Runner {
 		Cookies: cookies,
 	}
 
+	sessionId := newSessionId()
 	runner := &Runner{
-		DBConfig: RunDBConfig{
-			dbname:   "vocabularycom",
-			host:     "localhost",
-			port:     "5432",
-			user:     "postgres",
-			password: "password",
-		},
+		DBConfig:     params.DBConfig,
+		LLM:          provider,
+		Transport:    transport,
+		prompts:      prompts,
+		answerSchema: answerSchema,
+		llmModel:     params.LLM.Model,
+		explain:      params.LLM.Explain,
+		delay:        PRACTICE_DELAY,
+		log:          slog.Default().With("session_id", sessionId, "list_id", params.ListId),
 		ctx: &RunContext{
-			ListId:      params.ListId,
-			Cookies:     options.Cookies,
-			OllamaQuery: string(rawQuery),
+			SessionId: sessionId,
+			ListId:    params.ListId,
+			Cookies:   options.Cookies,
 		},
-		client:        cycletls.Init(),
 		clientOptions: options,
 	}
-	runner.initDb(runner.DBConfig)
+	if runner.llmModel == "" {
+		runner.llmModel = DEFAULT_LLM_MODEL
+	}
+	if runner.DBConfig == (RunDBConfig{}) {
+		runner.DBConfig = DefaultDBConfig()
+	}
+	storeDSN := params.StoreDSN
+	if storeDSN == "" {
+		storeDSN = runner.DBConfig.DSN()
+	}
+	store, err := db.Open(context.Background(), storeDSN)
+	if err != nil {
+		return nil, &StorageError{Op: "open store", Err: err}
+	}
+	runner.Store = store
+	closers = append(closers, store.Close)
 
-	return runner
+	if params.Journal != "" {
+		writer, err := journal.Open(params.Journal)
+		if err != nil {
+			return nil, err
+		}
+		runner.journal = writer
+		closers = append(closers, writer.Close)
+	}
+
+	if params.MediaDir != "" {
+		cache, err := media.NewCache(params.MediaDir)
+		if err != nil {
+			return nil, err
+		}
+		runner.Media = cache
+	}
+
+	return runner, nil
 }
 
-func (r *Runner) IsLoggedIn() bool {
-	res := false
+// Identifies the attempts made by one run of the tool.
+func newSessionId() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(buf)
+}
+
+func (r *Runner) IsLoggedIn(ctx context.Context) (bool, error) {
 	ME_URI := "https://www.vocabulary.com/auth/me.json"
 
-	fmt.Println("Checking if logged in...")
-	resp, err := r.client.Do(ME_URI, r.clientOptions, "GET")
+	r.log.Info("checking if logged in")
+	resp, err := r.do(ctx, ME_URI, "GET")
 	if err != nil {
-		fmt.Println("Error making HTTP request:", err)
-		res = false
+		return false, err
 	}
 
-	data := resp.JSONBody()
-	auth, ok := data["auth"].(map[string]interface{})
-	if !ok {
-		res = false
+	var data model.MeResponse
+	if err := json.Unmarshal([]byte(resp.Body), &data); err != nil {
+		return false, &ParseError{Field: "auth", Err: err}
 	}
 
-	loggedIn, ok := auth["loggedin"].(bool)
-	if ok {
-		res = loggedIn
-	}
-
-	return res
+	return data.Auth.LoggedIn, nil
 }
 
-func (r *Runner) Start(listId int) *model.Question {
+func (r *Runner) Start(ctx context.Context, listId int) (*model.Question, error) {
 	START_URI := "https://www.vocabulary.com/challenge/start.json"
 
 	requestPayload := model.StartPracticeReq{
@@ -151,9 +271,9 @@ func (r *Runner) Start(listId int) *model.Question {
 
 	if r.ctx.Secret != "" {
 		requestPayload.Secret = r.ctx.Secret
-		fmt.Println("Secret is not empty, continue from where we left off...")
+		r.log.Info("continuing the round from where we left off")
 	} else {
-		r.ctx.CurrentCompletionPercentage = 0
+		r.setProgress(0)
 	}
 
 	// Set body
@@ -161,219 +281,205 @@ func (r *Runner) Start(listId int) *model.Question {
 	r.clientOptions.Body = formData.Encode()
 	r.clientOptions.Headers["Content-Type"] = CONTENT_TYPE_URL_ENCODED
 
-	fmt.Println("Starting practice session...")
-	resp, err := r.client.Do(START_URI, r.clientOptions, "POST")
+	r.log.Info("starting practice session")
+	resp, err := r.do(ctx, START_URI, "POST")
 	if err != nil {
-		fmt.Println("Error making HTTP request:", err)
-		return nil
+		return nil, err
+	}
+	if resp.Status == 401 || resp.Status == 403 {
+		return nil, ErrNotLoggedIn
 	}
 
-	// Parse the JSON response
-	data := resp.JSONBody()
 	r.clientOptions.Cookies = utils.RetrieveCookies(resp.Cookies, r.clientOptions.Cookies)
 	r.clientOptions.Headers["Cookie"], _ = utils.GetCookiesString(r.clientOptions.Cookies)
-	if resp.Status == 400 {
-		roundOver, ok := data["error"].(string)
-		if ok {
-			if roundOver == "RestartChallengeException" {
-				fmt.Println("Encountered RestartChallengeException. Round over.")
-				r.ctx.CurrentCompletionPercentage = 1
-				r.ctx.CurrentQuestion = nil
-				return nil
-			}
-		}
+
+	// Parse the JSON response
+	data, err := utils.DecodeChallengeResponse([]byte(resp.Body), "secret")
+	if err != nil {
+		return nil, err
+	}
+	if resp.Status == 400 && data.Error == "RestartChallengeException" {
+		r.roundOver(data.Error)
+		r.ctx.CurrentQuestion = nil
+		return nil, ErrRoundOver
 	}
 
 	secret, err := utils.ExtractSecret(data)
 	if err != nil {
-		fmt.Println("Error extracting secret:", err)
-		return nil
+		return nil, err
 	}
 	r.ctx.Secret = secret
 
 	question, _, err := utils.ExtractQuestion(data)
 	if err != nil {
-		fmt.Println("Error extracting question:", err)
-		return nil
+		return nil, err
 	}
 	r.ctx.CurrentQuestion = question
-	r.SaveQuestionToDB(*question)
+	r.cacheImages(ctx, question)
+	if err := r.SaveQuestionToDB(ctx, *question); err != nil {
+		return nil, err
+	}
+	r.log.Info("question", "question_type", question.QuestionType, "question_id", question.ID)
+	r.recordQuestion(question)
 
 	progress, err := utils.ExtractPracticeProgress(data)
 	if err != nil {
-		fmt.Println("Error extracting progress, skipping...")
+		r.log.Warn("extracting progress, skipping", "error", err)
 	} else {
-		r.ctx.CurrentCompletionPercentage = *progress
+		r.setProgress(*progress)
+	}
+	if err := r.saveProgressSnapshot(ctx, data); err != nil {
+		return nil, err
+	}
+	if err := r.saveAccountSnapshot(ctx, data); err != nil {
+		return nil, err
 	}
 
-	return question
+	return question, nil
 }
 
-// Initializes db connection, and creates required tables.
-func (r *Runner) initDb(config RunDBConfig) {
-	connStr := fmt.Sprintf("postgresql://%s:%s@%s:%s/%s?sslmode=disable",
-		config.user, config.password, config.host, config.port, config.dbname)
-
-	conn, err := pgx.Connect(context.Background(), connStr)
-	if err != nil {
-		fmt.Println("Error connecting to database:", err)
-		panic(err)
+// Downloads the pictures of image choices when a media cache is configured.
+// A failed download only leaves MediaPath empty.
+func (r *Runner) cacheImages(ctx context.Context, question *model.Question) {
+	if r.Media == nil {
+		return
 	}
-	r.Conn = conn
-
-	query, err := os.ReadFile("./db/ddl.sql")
-	if err != nil {
-		fmt.Println("Error reading ddl.sql:", err)
-		panic(err)
+	for i, choice := range question.Choices {
+		if choice.ImageURL == "" {
+			continue
+		}
+		relPath, err := r.Media.Fetch(ctx, choice.ImageURL)
+		if err != nil {
+			r.log.Warn("caching image, skipping", "url", choice.ImageURL, "error", err)
+			continue
+		}
+		question.Choices[i].MediaPath = relPath
 	}
+}
 
-	_, err = r.Conn.Exec(context.Background(), string(query))
+func (r *Runner) SaveQuestionToDB(ctx context.Context, question model.Question) error {
+	id, err := r.Store.SaveQuestion(ctx, question)
 	if err != nil {
-		fmt.Println("Error executing ddl.sql:", err)
-		panic(err)
+		return &StorageError{Op: "save question", Err: err}
 	}
+	if r.ctx.CurrentQuestion != nil && r.ctx.CurrentQuestion.Code == question.Code {
+		r.ctx.CurrentQuestion.ID = id
+	}
+	return nil
 }
 
-func (r *Runner) SaveQuestionToDB(question model.Question) {
-
-	query := `
-		INSERT INTO question (
-			question_type,
-			question,
-			question_context,
-			question_code,
-			question_html,
-			answer,
-			answer_data_key,
-			difficulty,
-			choices,
-			correct,
-			target_word
-		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11    
-		)
-		ON CONFLICT (question_type, question_context, question) DO UPDATE SET
-			answer = $6,
-			answer_data_key = $7,
-			correct = $10,
-			target_word = $11
-		WHERE question.correct = FALSE
-	`
-
-	choicesJson, err1 := json.Marshal(question.Choices)
-	if err1 != nil {
-		fmt.Println("Error marshaling JSON:", err1)
-		choicesJson = nil
-	}
-
-	_, err := r.Conn.Exec(context.Background(), query,
-		question.QuestionType,
-		question.Question,
-		question.QuestionContext,
-		question.Code,
-		question.DecodedCode,
-		question.Answer,
-		question.AnswerKey,
-		question.Difficulty,
-		choicesJson,
-		question.IsCorrect,
-		question.TargetWord)
+// Keeps the game and pdata counters of a response for `report progress`.
+// Responses without a game object are skipped.
+func (r *Runner) saveProgressSnapshot(ctx context.Context, data *model.ChallengeResponse) error {
+	if data.Game == nil {
+		return nil
+	}
+	snapshot, err := utils.ExtractProgressSnapshot(data)
 	if err != nil {
-		fmt.Println("Error executing question insert query:", err)
-		panic(err)
+		return err
 	}
+	snapshot.SessionId = r.ctx.SessionId
+	if _, err := r.Store.SaveProgressSnapshot(ctx, *snapshot); err != nil {
+		return &StorageError{Op: "save progress snapshot", Err: err}
+	}
+	return nil
 }
 
-type OllamaPayload struct {
-	Context  string                  `json:"context"`
-	Question string                  `json:"question"`
-	Choices  []model.QuestionChoices `json:"choices"`
-}
-
-func (r *Runner) Ask(question model.Question) model.QuestionChoices {
-	LLM_URI := "http://localhost:11434/api/generate"
-
-	payload := &OllamaPayload{
-		Context:  question.QuestionContext,
-		Question: question.Question,
-		Choices:  question.Choices,
+// Keeps the level and word lists of a response's pdata for `lists`.
+// Responses without pdata are skipped.
+func (r *Runner) saveAccountSnapshot(ctx context.Context, data *model.ChallengeResponse) error {
+	if data.PData == nil {
+		return nil
 	}
-
-	payloadJson, err := json.Marshal(payload)
+	snapshot, err := utils.ExtractAccountSnapshot(data)
 	if err != nil {
-		fmt.Println("Error marshaling JSON:", err)
-		payloadJson = nil
+		return err
 	}
-	payloadString := strings.ReplaceAll(string(payloadJson), "\"", "\\\"")
-	query := fmt.Sprintf(r.ctx.OllamaQuery, payloadString)
-
-	req, err := http.NewRequest("POST", LLM_URI, bytes.NewBuffer([]byte(query)))
-	if err != nil {
-		fmt.Println("Error creating request:", err)
+	if _, err := r.Store.SaveAccountSnapshot(ctx, *snapshot); err != nil {
+		return &StorageError{Op: "save account snapshot", Err: err}
 	}
-	req.Header.Set("Content-Type", "application/json")
+	return nil
+}
 
-	// Send the request using an HTTP client
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		fmt.Println("Error sending request:", err)
+func (r *Runner) SaveAttemptToDB(ctx context.Context, attempt model.Attempt) error {
+	if _, err := r.Store.SaveAttempt(ctx, attempt); err != nil {
+		return &StorageError{Op: "save attempt", Err: err}
 	}
+	return nil
+}
 
-	// Read the response body
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		fmt.Println("Error reading response body:", err)
+func (r *Runner) Close() error {
+	var err error
+	if r.journal != nil {
+		err = r.journal.Close()
 	}
-
-	// Parse the JSON response
-	var data map[string]interface{}
-	if err := json.Unmarshal([]byte(body), &data); err != nil {
-		fmt.Println("Error decoding JSON response:", err)
-		panic(err)
+	err = errors.Join(err, r.Store.Close())
+	// CycleTLS without a worker pool holds nothing, and its Close would
+	// close nil channels, so only transports that are io.Closers are closed.
+	if closer, ok := r.Transport.(io.Closer); ok {
+		err = errors.Join(err, closer.Close())
 	}
+	return err
+}
 
-	response, ok := data["response"].(string)
-	if !ok {
-		err := errors.New("failed to decode response JSON")
-		panic(err)
+func (r *Runner) Ask(ctx context.Context, question model.Question) (model.QuestionChoices, error) {
+	system, user, err := r.prompts.Render("answer", prompt.DataFor(question))
+	if err != nil {
+		return model.QuestionChoices{}, &LLMError{Err: err}
 	}
 
-	// Parse the JSON response
-	var answerJson map[string]interface{}
-	if err := json.Unmarshal([]byte(response), &answerJson); err != nil {
-		fmt.Println("Error decoding JSON response:", err)
-		panic(err)
+	reply, err := r.LLM.Generate(ctx, llm.Request{
+		System: system,
+		Prompt: user,
+		Schema: r.answerSchema,
+	})
+	if err != nil {
+		return model.QuestionChoices{}, &LLMError{Err: err}
 	}
 
-	innerAnswerJson, ok := answerJson["answer"].(map[string]interface{})
-	if !ok {
-		err := errors.New("failed to decode answer JSON")
-		panic(err)
+	// The reply is JSON, shaped by the answer schema
+	var answerJson struct {
+		Answer *struct {
+			Answer string `json:"answer"`
+			Code   string `json:"code"`
+		} `json:"answer"`
 	}
-
-	answer, ok := innerAnswerJson["answer"].(string)
-	if !ok {
-		err := errors.New("failed to decode inner answer JSON")
-		panic(err)
+	if err := json.Unmarshal([]byte(reply), &answerJson); err != nil {
+		return model.QuestionChoices{}, &LLMError{Err: fmt.Errorf("decoding answer: %w", err)}
 	}
-
-	code, ok := innerAnswerJson["code"].(string)
-	if !ok {
-		err := errors.New("failed to decode code JSON")
-		panic(err)
+	if answerJson.Answer == nil || answerJson.Answer.Code == "" {
+		return model.QuestionChoices{}, &LLMError{Err: errors.New("answer has no code")}
 	}
 
+	answer := answerJson.Answer.Answer
+	code := answerJson.Answer.Code
 	r.ctx.CurrentQuestion.Answer = answer
 	r.ctx.CurrentQuestion.AnswerKey = code
-	return model.QuestionChoices{
+	suggestion := model.QuestionChoices{
 		Key:   code,
 		Value: answer,
 	}
+	r.recordChoice(journal.EVENT_SUGGESTION, suggestion)
+	return suggestion, nil
+}
+
+func findChoice(choices []model.QuestionChoices, key string) (model.QuestionChoices, bool) {
+	for _, choice := range choices {
+		if choice.Key == key {
+			return choice, true
+		}
+	}
+	return model.QuestionChoices{Key: key}, false
 }
 
-func (r *Runner) AnswerQuestion(answer model.QuestionChoices) {
+func (r *Runner) AnswerQuestion(ctx context.Context, answer model.QuestionChoices) error {
 	SAVE_ANSWER_URI := "https://www.vocabulary.com/challenge/saveanswer.json"
+	// Only the key is trusted, the LLM's text for it may be misspelt or
+	// paraphrased, so the choice's own value is what gets stored.
+	answer, ok := findChoice(r.ctx.CurrentQuestion.Choices, answer.Key)
+	if !ok {
+		return &LLMError{Err: fmt.Errorf("answer code %q is not one of the choices", answer.Key)}
+	}
 	// Send request, update secret, get next question after this method.
 	requestPayload := model.AnswerReq{
 		Secret: r.ctx.Secret,
@@ -387,71 +493,76 @@ func (r *Runner) AnswerQuestion(answer model.QuestionChoices) {
 	r.clientOptions.Body = formData.Encode()
 	r.clientOptions.Headers["Content-Type"] = CONTENT_TYPE_URL_ENCODED
 
-	fmt.Println("Answering question...")
-	resp, err := r.client.Do(SAVE_ANSWER_URI, r.clientOptions, "POST")
+	r.log.Info("answering question", "question_type", r.ctx.CurrentQuestion.QuestionType, "question_id", r.ctx.CurrentQuestion.ID)
+	r.recordChoice(journal.EVENT_ANSWER, answer)
+	resp, err := r.do(ctx, SAVE_ANSWER_URI, "POST")
 	if err != nil {
-		fmt.Println("Error making HTTP request:", err)
-		panic(err)
+		return err
+	}
+	if resp.Status == 401 || resp.Status == 403 {
+		return ErrNotLoggedIn
 	}
 
-	// Parse the JSON response
-	data := resp.JSONBody()
 	r.clientOptions.Cookies = utils.RetrieveCookies(resp.Cookies, r.clientOptions.Cookies)
 	r.clientOptions.Headers["Cookie"], _ = utils.GetCookiesString(r.clientOptions.Cookies)
-	if resp.Status == 400 {
-		roundOver, ok := data["error"].(string)
-		if ok {
-			if roundOver == "RestartChallengeException" {
-				fmt.Println("Encountered RestartChallengeException. Round over.")
-				r.ctx.CurrentCompletionPercentage = 1
-				r.ctx.CurrentQuestion = &model.Question{}
-				return
-			}
-		}
-	}
 
-	secret, err := utils.ExtractSecret(data)
+	// Parse the JSON response
+	data, err := utils.DecodeChallengeResponse([]byte(resp.Body),
+		"secret", "answer.correct", "answer.word", "game.progress")
 	if err != nil {
-		fmt.Println("Error extracting secret:", err)
-		panic(err)
-	}
-	r.ctx.Secret = secret
-
-	answerJson, ok := data["answer"].(map[string]interface{})
-	if !ok {
-		err := errors.New("failed to decode answer JSON")
-		panic(err)
+		return err
 	}
-	wasCorrect, ok := answerJson["correct"].(bool)
-	if !ok {
-		err := errors.New("failed to decode wasCorrect JSON")
-		panic(err)
-	}
-	targetWord, ok := answerJson["word"].(string)
-	if !ok {
-		err := errors.New("failed to decode target word JSON")
-		panic(err)
+	if resp.Status == 400 && data.Error == "RestartChallengeException" {
+		r.roundOver(data.Error)
+		r.ctx.CurrentQuestion = &model.Question{}
+		return ErrRoundOver
 	}
 
+	secret, err := utils.ExtractSecret(data)
+	if err != nil {
+		return err
+	}
 	r.ctx.Secret = secret
 
 	r.ctx.CurrentQuestion.Answer = answer.Value
 	r.ctx.CurrentQuestion.AnswerKey = answer.Key
-	r.ctx.CurrentQuestion.TargetWord = targetWord
-	r.ctx.CurrentQuestion.IsCorrect = wasCorrect
-	r.ctx.PointsEarned = answerJson["points"].(int) + answerJson["bonus"].(int)
-
-	r.SaveQuestionToDB(*r.ctx.CurrentQuestion)
+	r.ctx.CurrentQuestion.TargetWord = data.Answer.Word
+	r.ctx.CurrentQuestion.IsCorrect = data.Answer.Correct
+	r.ctx.PointsEarned = data.Answer.Points + data.Answer.Bonus
+
+	if err := r.SaveQuestionToDB(ctx, *r.ctx.CurrentQuestion); err != nil {
+		return err
+	}
+	attempt := model.Attempt{
+		QuestionID:   r.ctx.CurrentQuestion.ID,
+		ChoiceKey:    answer.Key,
+		ChoiceValue:  answer.Value,
+		IsCorrect:    data.Answer.Correct,
+		Points:       data.Answer.Points,
+		Bonus:        data.Answer.Bonus,
+		ResponseTime: requestPayload.Rt,
+		SessionId:    r.ctx.SessionId,
+		// Set here so the journal and the store agree on it.
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := r.SaveAttemptToDB(ctx, attempt); err != nil {
+		return err
+	}
+	answered := *r.ctx.CurrentQuestion
+	r.record(journal.Event{Type: journal.EVENT_RESULT, QuestionID: answered.ID, Question: &answered, Attempt: &attempt})
+	r.log.Info("answered", "question_type", r.ctx.CurrentQuestion.QuestionType, "question_id", r.ctx.CurrentQuestion.ID,
+		"correct", data.Answer.Correct, "points", r.ctx.PointsEarned)
+	r.explainAnswer(ctx, *r.ctx.CurrentQuestion)
 
 	progress, err := utils.ExtractPracticeProgress(data)
 	if err != nil {
-		fmt.Println("Error extracting progress:", err)
-		panic(err)
+		return err
 	}
-	r.ctx.CurrentCompletionPercentage = *progress
+	r.setProgress(*progress)
+	return r.saveProgressSnapshot(ctx, data)
 }
 
-func (r *Runner) NextQuestion() *model.Question {
+func (r *Runner) NextQuestion(ctx context.Context) (*model.Question, error) {
 	// To be called after answerQuestion()
 	NEXT_QUESTION_URI := "https://www.vocabulary.com/challenge/nextquestion.json"
 	requestPayload := model.NextQuestionReq{
@@ -464,61 +575,164 @@ func (r *Runner) NextQuestion() *model.Question {
 	r.clientOptions.Body = formData.Encode()
 	r.clientOptions.Headers["Content-Type"] = CONTENT_TYPE_URL_ENCODED
 
-	fmt.Println("Fetching next question...")
-	resp, err := r.client.Do(NEXT_QUESTION_URI, r.clientOptions, "POST")
+	r.log.Info("fetching next question")
+	resp, err := r.do(ctx, NEXT_QUESTION_URI, "POST")
 	if err != nil {
-		fmt.Println("Error making HTTP request:", err)
-		panic(err)
+		return nil, err
+	}
+	if resp.Status == 401 || resp.Status == 403 {
+		return nil, ErrNotLoggedIn
 	}
 
-	// Parse the JSON response
-	data := resp.JSONBody()
 	r.clientOptions.Cookies = utils.RetrieveCookies(resp.Cookies, r.clientOptions.Cookies)
 	r.clientOptions.Headers["Cookie"], _ = utils.GetCookiesString(r.clientOptions.Cookies)
+
+	// Parse the JSON response
+	data, err := utils.DecodeChallengeResponse([]byte(resp.Body), "secret")
+	if err != nil {
+		return nil, err
+	}
+	if resp.Status == 400 && data.Error == "RestartChallengeException" {
+		r.roundOver(data.Error)
+		return nil, ErrRoundOver
+	}
 	secret, err := utils.ExtractSecret(data)
 	if err != nil {
-		fmt.Println("Error extracting secret:", err)
-		panic(err)
-	} else {
-		r.ctx.Secret = secret
+		return nil, err
 	}
+	r.ctx.Secret = secret
 
-	question, _, _ := utils.ExtractQuestion(data)
+	question, _, err := utils.ExtractQuestion(data)
+	if err != nil {
+		return nil, err
+	}
 	r.ctx.CurrentQuestion = question
-	r.SaveQuestionToDB(*question)
+	r.cacheImages(ctx, question)
+	if err := r.SaveQuestionToDB(ctx, *question); err != nil {
+		return nil, err
+	}
+	r.log.Info("question", "question_type", question.QuestionType, "question_id", question.ID)
+	r.recordQuestion(question)
 
 	progress, err := utils.ExtractPracticeProgress(data)
 	if err != nil {
-		fmt.Println("Error extracting progress, skipping...")
+		r.log.Warn("extracting progress, skipping", "error", err)
 	} else {
-		r.ctx.CurrentCompletionPercentage = *progress
+		r.setProgress(*progress)
+	}
+	if err := r.saveProgressSnapshot(ctx, data); err != nil {
+		return nil, err
+	}
+	if err := r.saveAccountSnapshot(ctx, data); err != nil {
+		return nil, err
 	}
 
-	return question
+	return question, nil
 }
 
-func (r *Runner) Practice() {
-	r.ctx.CurrentQuestion = r.Start(r.ctx.ListId)
-	// r.ctx.CurrentQuestion = r.NextQuestion()
+// Practice answers questions until ctx is cancelled or something fails. It
+// picks up where the checkpoint of the list left off, and checkpoints the
+// session again when it stops.
+func (r *Runner) Practice(ctx context.Context) (err error) {
+	r.record(journal.Event{Type: journal.EVENT_SESSION_START})
+	defer func() {
+		if err != nil {
+			r.record(journal.Event{Type: journal.EVENT_ERROR, Error: err.Error()})
+		}
+	}()
+
+	loggedIn, err := r.IsLoggedIn(ctx)
+	if err != nil {
+		return err
+	}
+	if !loggedIn {
+		return ErrNotLoggedIn
+	}
+	if err := r.Resume(ctx); err != nil {
+		return err
+	}
+	defer func() {
+		// Still saved when ctx is what stopped the session.
+		if err := r.Checkpoint(context.WithoutCancel(ctx)); err != nil {
+			r.log.Error("saving checkpoint", "error", err)
+		}
+	}()
+
+	question, err := r.Start(ctx, r.ctx.ListId)
+	if errors.Is(err, ErrRoundOver) {
+		// The stored secret belongs to a finished round, start a fresh one.
+		r.ctx.Secret = ""
+		question, err = r.Start(ctx, r.ctx.ListId)
+	}
+	if err != nil {
+		return err
+	}
+
 	for {
-		answer := r.Ask(*r.ctx.CurrentQuestion)
+		answer, err := r.Ask(ctx, *question)
+		if err != nil {
+			return err
+		}
 
-		time.Sleep(3 * time.Second)
-		r.AnswerQuestion(answer)
+		if err := sleep(ctx, r.delay); err != nil {
+			return err
+		}
+		err = r.AnswerQuestion(ctx, answer)
+		if err != nil && !errors.Is(err, ErrRoundOver) {
+			return err
+		}
 
-		if r.ctx.CurrentCompletionPercentage == 1 {
-			fmt.Println("Round over. Restarting challenge...")
+		if errors.Is(err, ErrRoundOver) || r.ctx.CurrentCompletionPercentage == 1 {
+			r.log.Info("round over, restarting challenge")
 			r.ctx.Secret = ""
-			r.ctx.CurrentQuestion = r.Start(r.ctx.ListId)
+			if err := sleep(ctx, r.delay); err != nil {
+				return err
+			}
+			question, err = r.Start(ctx, r.ctx.ListId)
+			if err != nil {
+				return err
+			}
+			continue
+		}
 
-			time.Sleep(3 * time.Second)
-			r.AnswerQuestion(answer)
+		if err := sleep(ctx, r.delay); err != nil {
+			return err
+		}
+		question, err = r.NextQuestion(ctx)
+		if err != nil {
+			return err
 		}
 
-		time.Sleep(3 * time.Second)
-		r.ctx.CurrentQuestion = r.NextQuestion()
+		r.log.Debug("sleeping", "delay", r.delay)
+		if err := sleep(ctx, r.delay); err != nil {
+			return err
+		}
+	}
+}
 
-		fmt.Println("Sleeping for 3 seconds...")
-		time.Sleep(3 * time.Second)
+// do sends the request prepared in clientOptions, unless ctx is done. A
+// request already sent cannot be interrupted.
+func (r *Runner) do(ctx context.Context, uri, method string) (cycletls.Response, error) {
+	if err := ctx.Err(); err != nil {
+		return cycletls.Response{}, err
+	}
+	started := time.Now()
+	resp, err := r.Transport.Do(uri, r.clientOptions, method)
+	if err != nil {
+		return resp, fmt.Errorf("requesting %s: %w", uri, err)
+	}
+	r.log.Debug("request", "endpoint", uri, "method", method, "status", resp.Status, "duration", time.Since(started))
+	return resp, nil
+}
+
+// Waits for d, or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
 	}
 }
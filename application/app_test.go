@@ -0,0 +1,385 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"github.com/rodatboat/go-vocab/cassette"
+	"github.com/rodatboat/go-vocab/db"
+	"github.com/rodatboat/go-vocab/internal/fakesite"
+	"github.com/rodatboat/go-vocab/journal"
+	"github.com/rodatboat/go-vocab/llm"
+	"github.com/rodatboat/go-vocab/logging"
+	"github.com/rodatboat/go-vocab/model"
+)
+
+// A session recorded against example/example.start.json and
+// example/example.next.json: log in, start, answer, next question, and a
+// second answer that ends the round.
+const practiceCassette = "testdata/practice.cassette.json"
+
+func replayRunner(t *testing.T, path string) (*Runner, *cassette.Replayer) {
+	t.Helper()
+	runner, err := New(RunParams{
+		ListId:       2444808,
+		StoreDSN:     "memory://",
+		Cassette:     path,
+		CassetteMode: CASSETTE_REPLAY,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { runner.Close() })
+	return runner, runner.Transport.(*cassette.Replayer)
+}
+
+func TestReplayPracticeSession(t *testing.T) {
+	ctx := context.Background()
+	r, replayer := replayRunner(t, practiceCassette)
+
+	loggedIn, err := r.IsLoggedIn(ctx)
+	if err != nil || !loggedIn {
+		t.Fatalf("IsLoggedIn = %v, %v", loggedIn, err)
+	}
+
+	question, err := r.Start(ctx, r.ctx.ListId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if question.QuestionType != "H" || question.TargetWord != "diffused" || question.ID == 0 {
+		t.Errorf("started with %+v", question)
+	}
+	startSecret := r.ctx.Secret
+
+	remaining := replayer.Remaining()
+	var llmErr *LLMError
+	if err := r.AnswerQuestion(ctx, model.QuestionChoices{Key: "nope", Value: "dispersed"}); !errors.As(err, &llmErr) {
+		t.Errorf("want LLMError for a key not among the choices, got %v", err)
+	}
+	if replayer.Remaining() != remaining {
+		t.Errorf("an answer with an unknown key was sent, %d interactions left", replayer.Remaining())
+	}
+	// The key is right, the text is the LLM's misspelling.
+	if err := r.AnswerQuestion(ctx, model.QuestionChoices{Key: "anz6wy", Value: "disperesd"}); err != nil {
+		t.Fatal(err)
+	}
+	if r.ctx.Secret == startSecret || r.ctx.PointsEarned != 120 || r.ctx.CurrentCompletionPercentage != 0.4 {
+		t.Errorf("after answering: secret changed %v, points %d, progress %v",
+			r.ctx.Secret != startSecret, r.ctx.PointsEarned, r.ctx.CurrentCompletionPercentage)
+	}
+	attempts, err := r.Store.ListAttempts(ctx, db.AttemptFilter{QuestionID: question.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attempts) != 1 || !attempts[0].IsCorrect || attempts[0].ChoiceValue != "dispersed" {
+		t.Errorf("attempts = %+v", attempts)
+	}
+	stored, err := r.Store.GetQuestion(ctx, question.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stored.IsCorrect || stored.AnswerKey != "anz6wy" || stored.Answer != "dispersed" {
+		t.Errorf("stored question = %+v", stored)
+	}
+
+	question, err = r.NextQuestion(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if question.QuestionType != "I" || len(question.Choices) != 4 {
+		t.Errorf("next question = %+v", question)
+	}
+
+	err = r.AnswerQuestion(ctx, model.QuestionChoices{Key: "7yll8j"})
+	if !errors.Is(err, ErrRoundOver) || r.ctx.CurrentCompletionPercentage != 1 {
+		t.Errorf("want ErrRoundOver at progress 1, got %v at %v", err, r.ctx.CurrentCompletionPercentage)
+	}
+
+	snapshots, err := r.Store.ListProgressSnapshots(ctx, db.ProgressFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 3 {
+		t.Errorf("want a progress snapshot per challenge response, got %d", len(snapshots))
+	}
+	if replayer.Remaining() != 0 {
+		t.Errorf("%d interactions not replayed", replayer.Remaining())
+	}
+}
+
+func TestReplayOutOfOrder(t *testing.T) {
+	r, _ := replayRunner(t, practiceCassette)
+	if _, err := r.Start(context.Background(), r.ctx.ListId); err == nil {
+		t.Error("want an error when the session does not follow the cassette")
+	}
+}
+
+func TestLogAttributes(t *testing.T) {
+	var out bytes.Buffer
+	handler, err := logging.NewHandler(&out, logging.FORMAT_JSON, slog.LevelDebug)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(handler))
+
+	r, _ := replayRunner(t, practiceCassette)
+	ctx := context.Background()
+	if _, err := r.IsLoggedIn(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Start(ctx, r.ctx.ListId); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(out.Bytes(), []byte(r.ctx.Secret)) {
+		t.Error("log contains the secret")
+	}
+
+	var endpoints, questions int
+	for _, line := range bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n")) {
+		var record map[string]interface{}
+		if err := json.Unmarshal(line, &record); err != nil {
+			t.Fatalf("not a JSON record: %s", line)
+		}
+		if record["session_id"] != r.ctx.SessionId || record["list_id"] != 2444808.0 {
+			t.Errorf("record without session: %s", line)
+		}
+		if record["endpoint"] != nil {
+			endpoints++
+		}
+		if record["msg"] == "question" && record["question_type"] == "H" {
+			questions++
+		}
+	}
+	if endpoints != 2 || questions != 1 {
+		t.Errorf("logged %d requests and %d questions:\n%s", endpoints, questions, out.String())
+	}
+}
+
+var errEnough = errors.New("enough questions")
+
+// firstChoice answers every question with its first choice, which the fake
+// site takes as right. Past limit questions it fails, or when cancel is set
+// cancels the session and answers anyway.
+type firstChoice struct {
+	asked  int
+	limit  int
+	cancel context.CancelFunc
+}
+
+func (f *firstChoice) Generate(ctx context.Context, req llm.Request) (string, error) {
+	f.asked++
+	if f.asked > f.limit {
+		if f.cancel == nil {
+			return "", errEnough
+		}
+		f.cancel()
+	}
+	var prompt struct {
+		Choices []model.QuestionChoices `json:"choices"`
+	}
+	if err := json.Unmarshal([]byte(req.Prompt), &prompt); err != nil {
+		return "", err
+	}
+	reply, err := json.Marshal(map[string]interface{}{
+		"question": "",
+		"answer":   map[string]string{"answer": prompt.Choices[0].Value, "code": prompt.Choices[0].Key},
+	})
+	return string(reply), err
+}
+
+func newSite(t *testing.T, roundLength int) *fakesite.Site {
+	t.Helper()
+	site, err := fakesite.New("../example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	site.RoundLength = roundLength
+	t.Cleanup(site.Close)
+	return site
+}
+
+// siteRunner is a Runner on list 2444808 talking to site, without pauses.
+// The store is in memory unless params has one.
+func siteRunner(t *testing.T, site *fakesite.Site, params RunParams) *Runner {
+	t.Helper()
+	params.ListId = 2444808
+	if params.StoreDSN == "" {
+		params.StoreDSN = "memory://"
+	}
+	runner, err := New(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { runner.Close() })
+	runner.Transport = site
+	runner.delay = 0
+	return runner
+}
+
+func TestPracticeRounds(t *testing.T) {
+	ctx := context.Background()
+	site := newSite(t, 3)
+	r := siteRunner(t, site, RunParams{JSessionId: "session"})
+	r.LLM = &firstChoice{limit: 6}
+	// Left over from an earlier run, so Practice has to start afresh.
+	r.ctx.Secret = "stale-secret"
+
+	err := r.Practice(ctx)
+	if !errors.Is(err, errEnough) {
+		t.Fatalf("want the LLM to stop practice, got %v", err)
+	}
+	// The third answer of each round is refused, the seventh question is
+	// never answered.
+	if site.Rounds() != 3 {
+		t.Errorf("rounds = %d, want 3", site.Rounds())
+	}
+	attempts, err := r.Store.ListAttempts(ctx, db.AttemptFilter{SessionId: r.ctx.SessionId})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attempts) != 4 {
+		t.Errorf("attempts = %d, want 4", len(attempts))
+	}
+	for _, attempt := range attempts {
+		if !attempt.IsCorrect {
+			t.Errorf("attempt %+v is not correct", attempt)
+		}
+	}
+	snapshots, err := r.Store.ListProgressSnapshots(ctx, db.ProgressFilter{ListId: 2444808})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) == 0 || snapshots[len(snapshots)-1].Played != 0 {
+		t.Errorf("want the last snapshot from the start of round 3, got %+v", snapshots)
+	}
+	accounts, err := r.Store.ListAccountSnapshots(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accounts) == 0 || len(accounts[len(accounts)-1].Lists) == 0 {
+		t.Errorf("want the level and lists of every question saved, got %+v", accounts)
+	}
+}
+
+func TestPracticeNotLoggedIn(t *testing.T) {
+	site := newSite(t, 3)
+	r := siteRunner(t, site, RunParams{})
+	r.LLM = &firstChoice{}
+	if err := r.Practice(context.Background()); !errors.Is(err, ErrNotLoggedIn) {
+		t.Errorf("want ErrNotLoggedIn, got %v", err)
+	}
+	if site.Rounds() != 0 {
+		t.Error("a round was started without a session")
+	}
+}
+
+func TestPracticeResumesCheckpoint(t *testing.T) {
+	site := newSite(t, 10)
+	params := RunParams{
+		JSessionId: "session",
+		StoreDSN:   "sqlite://" + filepath.Join(t.TempDir(), "vocab.db"),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	first := siteRunner(t, site, params)
+	first.LLM = &firstChoice{limit: 2, cancel: cancel}
+	if err := first.Practice(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("want context.Canceled, got %v", err)
+	}
+	open := first.ctx.CurrentQuestion
+
+	checkpoint, err := first.Store.GetCheckpoint(context.Background(), 2444808)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checkpoint.Secret == "" || checkpoint.Secret != first.ctx.Secret ||
+		checkpoint.Progress != 0.2 || checkpoint.QuestionID != open.ID {
+		t.Errorf("checkpoint = %+v", checkpoint)
+	}
+
+	second := siteRunner(t, site, params)
+	second.LLM = &firstChoice{limit: 1}
+	if err := second.Practice(context.Background()); !errors.Is(err, errEnough) {
+		t.Fatalf("want the LLM to stop practice, got %v", err)
+	}
+	if site.Rounds() != 1 {
+		t.Errorf("rounds = %d, want the first round resumed", site.Rounds())
+	}
+	attempts, err := second.Store.ListAttempts(context.Background(), db.AttemptFilter{QuestionID: open.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attempts) != 1 || attempts[0].SessionId != second.ctx.SessionId {
+		t.Errorf("want the open question answered after resuming, got %+v", attempts)
+	}
+	checkpoint, err = second.Store.GetCheckpoint(context.Background(), 2444808)
+	if err != nil || checkpoint.Progress != 0.3 {
+		t.Errorf("checkpoint after resuming = %+v (%v)", checkpoint, err)
+	}
+}
+
+func TestPracticeJournal(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	r := siteRunner(t, newSite(t, 3), RunParams{JSessionId: "session", Journal: path})
+	r.LLM = &firstChoice{limit: 3}
+	if err := r.Practice(ctx); !errors.Is(err, errEnough) {
+		t.Fatalf("want the LLM to stop practice, got %v", err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	counts := map[string]int{}
+	var last int64
+	err := journal.ReadFile(path, func(event journal.Event) error {
+		if event.Seq != last+1 || event.SessionId != r.ctx.SessionId || event.ListId != 2444808 {
+			t.Errorf("event after %d = %+v", last, event)
+		}
+		last = event.Seq
+		counts[event.Type]++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Two answers saved, the third refused as the round is over, and the
+	// fourth question asked of an LLM that gives up.
+	want := map[string]int{
+		journal.EVENT_SESSION_START: 1,
+		journal.EVENT_QUESTION:      4,
+		journal.EVENT_SUGGESTION:    3,
+		journal.EVENT_ANSWER:        3,
+		journal.EVENT_RESULT:        2,
+		journal.EVENT_ROUND_OVER:    1,
+		journal.EVENT_ERROR:         1,
+	}
+	for eventType, n := range want {
+		if counts[eventType] != n {
+			t.Errorf("%d %s events, want %d: %v", counts[eventType], eventType, n, counts)
+		}
+	}
+	if counts[journal.EVENT_PROGRESS] == 0 {
+		t.Error("no progress events")
+	}
+
+	store := db.NewMemoryStore()
+	replayer := journal.NewReplayer(store)
+	if err := replayer.ReplayFile(ctx, path); err != nil {
+		t.Fatal(err)
+	}
+	attempts, err := store.ListAttempts(ctx, db.AttemptFilter{SessionId: r.ctx.SessionId})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attempts) != 2 || replayer.Stats.Questions != 4 {
+		t.Errorf("replayed %d attempts, stats %+v", len(attempts), replayer.Stats)
+	}
+}
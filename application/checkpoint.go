@@ -0,0 +1,56 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rodatboat/go-vocab/db"
+	"github.com/rodatboat/go-vocab/model"
+)
+
+// Checkpoint saves where the session on the current list is, so the next
+// Practice resumes the round with its secret.
+func (r *Runner) Checkpoint(ctx context.Context) error {
+	checkpoint := model.Checkpoint{
+		ListId:    r.ctx.ListId,
+		SessionId: r.ctx.SessionId,
+		Secret:    r.ctx.Secret,
+		Progress:  r.ctx.CurrentCompletionPercentage,
+		SavedAt:   time.Now().UTC(),
+	}
+	if r.ctx.CurrentQuestion != nil {
+		checkpoint.QuestionID = r.ctx.CurrentQuestion.ID
+	}
+	if err := r.Store.SaveCheckpoint(ctx, checkpoint); err != nil {
+		return &StorageError{Op: "save checkpoint", Err: err}
+	}
+	return nil
+}
+
+// Resume loads the checkpoint of the current list, if there is one. A
+// question that can no longer be read is skipped, Start fetches it again.
+func (r *Runner) Resume(ctx context.Context) error {
+	checkpoint, err := r.Store.GetCheckpoint(ctx, r.ctx.ListId)
+	if errors.Is(err, db.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return &StorageError{Op: "load checkpoint", Err: err}
+	}
+
+	r.ctx.Secret = checkpoint.Secret
+	r.ctx.CurrentCompletionPercentage = checkpoint.Progress
+	if checkpoint.QuestionID != 0 {
+		question, err := r.Store.GetQuestion(ctx, checkpoint.QuestionID)
+		if err != nil {
+			r.log.Warn("loading checkpoint question, skipping", "question_id", checkpoint.QuestionID, "error", err)
+		} else {
+			r.ctx.CurrentQuestion = question
+		}
+	}
+	if checkpoint.Secret != "" {
+		r.log.Info("resuming from checkpoint", "saved_at", checkpoint.SavedAt, "progress", checkpoint.Progress)
+	}
+	return nil
+}
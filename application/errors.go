@@ -0,0 +1,47 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/rodatboat/go-vocab/utils"
+)
+
+var (
+	// The session cookies are missing or expired.
+	ErrNotLoggedIn = errors.New("not logged in")
+	// The server answered with RestartChallengeException, the round has to be started again.
+	ErrRoundOver = errors.New("round over")
+	// A challenge response did not carry a secret to continue the session with.
+	ErrSecretMissing = utils.ErrSecretMissing
+)
+
+// ParseError reports the response or slide field that was missing or mistyped.
+type ParseError = utils.ParseError
+
+// LLMError wraps any failure to get a usable answer out of the language model.
+type LLMError struct {
+	Err error
+}
+
+func (e *LLMError) Error() string {
+	return fmt.Sprintf("llm: %v", e.Err)
+}
+
+func (e *LLMError) Unwrap() error {
+	return e.Err
+}
+
+// StorageError wraps a failed database operation. Op names what was attempted.
+type StorageError struct {
+	Op  string
+	Err error
+}
+
+func (e *StorageError) Error() string {
+	return fmt.Sprintf("storage: %s: %v", e.Op, e.Err)
+}
+
+func (e *StorageError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,86 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rodatboat/go-vocab/db"
+	"github.com/rodatboat/go-vocab/llm"
+	"github.com/rodatboat/go-vocab/model"
+	"github.com/rodatboat/go-vocab/prompt"
+)
+
+// Explain asks the LLM why the answer of a question is right, with a one
+// line definition and a mnemonic, and saves it. The question must be stored
+// and answered.
+func (r *Runner) Explain(ctx context.Context, question model.Question) (*model.Explanation, error) {
+	if question.ID == 0 || question.Answer == "" {
+		return nil, &LLMError{Err: errors.New("only a stored, answered question can be explained")}
+	}
+
+	system, user, err := r.prompts.Render("explain", prompt.DataFor(question))
+	if err != nil {
+		return nil, &LLMError{Err: err}
+	}
+	schema, err := prompt.Schema("explain")
+	if err != nil {
+		return nil, &LLMError{Err: err}
+	}
+	reply, err := r.LLM.Generate(ctx, llm.Request{
+		System: system,
+		Prompt: user,
+		Schema: schema,
+	})
+	if err != nil {
+		return nil, &LLMError{Err: err}
+	}
+
+	var explained struct {
+		Explanation string `json:"explanation"`
+		Definition  string `json:"definition"`
+		Mnemonic    string `json:"mnemonic"`
+	}
+	if err := json.Unmarshal([]byte(reply), &explained); err != nil {
+		return nil, &LLMError{Err: fmt.Errorf("decoding explanation: %w", err)}
+	}
+	if explained.Explanation == "" {
+		return nil, &LLMError{Err: errors.New("explanation is empty")}
+	}
+
+	explanation := model.Explanation{
+		QuestionID:  question.ID,
+		Explanation: explained.Explanation,
+		Definition:  explained.Definition,
+		Mnemonic:    explained.Mnemonic,
+		Model:       r.llmModel,
+		CreatedAt:   time.Now().UTC(),
+	}
+	if err := r.Store.SaveExplanation(ctx, explanation); err != nil {
+		return nil, &StorageError{Op: "save explanation", Err: err}
+	}
+	return &explanation, nil
+}
+
+// Explains a correctly answered question the first time it is seen, when
+// explanations are enabled. A failure only skips the explanation.
+func (r *Runner) explainAnswer(ctx context.Context, question model.Question) {
+	if !r.explain || !question.IsCorrect {
+		return
+	}
+	_, err := r.Store.GetExplanation(ctx, question.ID)
+	if err == nil {
+		return
+	}
+	if !errors.Is(err, db.ErrNotFound) {
+		r.log.Warn("reading explanation, skipping", "question_id", question.ID, "error", err)
+		return
+	}
+
+	r.log.Info("explaining answer", "question_type", question.QuestionType, "question_id", question.ID)
+	if _, err := r.Explain(ctx, question); err != nil {
+		r.log.Warn("explaining answer, skipping", "question_id", question.ID, "error", err)
+	}
+}
@@ -0,0 +1,47 @@
+package application
+
+import (
+	"github.com/rodatboat/go-vocab/journal"
+	"github.com/rodatboat/go-vocab/model"
+)
+
+// record appends event to the journal, when one is configured. A journal
+// that cannot be written does not stop the session, the store still has it.
+func (r *Runner) record(event journal.Event) {
+	if r.journal == nil {
+		return
+	}
+	event.SessionId = r.ctx.SessionId
+	event.ListId = r.ctx.ListId
+	if err := r.journal.Append(event); err != nil {
+		r.log.Warn("writing journal, skipping", "event", event.Type, "error", err)
+	}
+}
+
+func (r *Runner) recordQuestion(question *model.Question) {
+	copied := *question
+	r.record(journal.Event{Type: journal.EVENT_QUESTION, QuestionID: question.ID, Question: &copied})
+}
+
+func (r *Runner) recordChoice(eventType string, choice model.QuestionChoices) {
+	event := journal.Event{Type: eventType, Choice: &choice}
+	if r.ctx.CurrentQuestion != nil {
+		event.QuestionID = r.ctx.CurrentQuestion.ID
+	}
+	r.record(event)
+}
+
+// setProgress records the completion percentage when it changes.
+func (r *Runner) setProgress(progress float64) {
+	if progress == r.ctx.CurrentCompletionPercentage {
+		return
+	}
+	r.ctx.CurrentCompletionPercentage = progress
+	r.record(journal.Event{Type: journal.EVENT_PROGRESS, Progress: progress})
+}
+
+func (r *Runner) roundOver(reason string) {
+	r.log.Info("round over", "error", reason)
+	r.setProgress(1)
+	r.record(journal.Event{Type: journal.EVENT_ROUND_OVER, Error: reason})
+}
@@ -0,0 +1,105 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rodatboat/go-vocab/llm"
+	"github.com/rodatboat/go-vocab/prompt"
+)
+
+// LLMProvider sends one prompt to a language model and returns its reply.
+// The backends are in package llm; tests can point one at an httptest
+// server or swap Runner.LLM for a stub.
+type LLMProvider interface {
+	Generate(ctx context.Context, req llm.Request) (string, error)
+}
+
+const (
+	LLM_OLLAMA_GENERATE = "ollama-generate"
+	LLM_OLLAMA_CHAT     = "ollama-chat"
+	LLM_OPENAI          = "openai"
+)
+
+var LLM_PROVIDERS = []string{LLM_OLLAMA_GENERATE, LLM_OLLAMA_CHAT, LLM_OPENAI}
+
+const DEFAULT_OLLAMA_CHAT_URL = "http://localhost:11434/api/chat"
+
+// llama.cpp server's default port. vLLM listens on 8000.
+const DEFAULT_OPENAI_URL = "http://localhost:8080/v1/chat/completions"
+const DEFAULT_LLM_MODEL = "llama3.1:8b-instruct-q5_K_S"
+const DEFAULT_LLM_TIMEOUT = 2 * time.Minute
+
+type LLMConfig struct {
+	// One of LLM_PROVIDERS, LLM_OLLAMA_GENERATE when empty.
+	Provider string
+	// Zero values fall back to the provider's default URL, DEFAULT_LLM_MODEL
+	// and DEFAULT_LLM_TIMEOUT. A zero temperature is used as is.
+	URL         string
+	Model       string
+	Temperature float64
+	Timeout     time.Duration
+	APIKey      string
+	// JSON schema file the answers must follow, instead of the built-in
+	// prompt/templates/answer.schema.json.
+	SchemaFile string
+	// Prompt template overrides, see package prompt.
+	PromptDir string
+	// Ask for an explanation of every question answered correctly.
+	Explain bool
+	// OpenAI's strict schema mode, see llm.OpenAI.
+	Strict bool
+}
+
+func NewLLMProvider(c LLMConfig) (LLMProvider, error) {
+	options := llm.Options{
+		URL:         c.URL,
+		Model:       c.Model,
+		Temperature: c.Temperature,
+		Timeout:     c.Timeout,
+		APIKey:      c.APIKey,
+	}
+	if options.Model == "" {
+		options.Model = DEFAULT_LLM_MODEL
+	}
+	if options.Timeout == 0 {
+		options.Timeout = DEFAULT_LLM_TIMEOUT
+	}
+
+	switch c.Provider {
+	case LLM_OLLAMA_GENERATE, "":
+		if options.URL == "" {
+			options.URL = DEFAULT_OLLAMA_URL
+		}
+		return &llm.OllamaGenerate{Options: options}, nil
+	case LLM_OLLAMA_CHAT:
+		if options.URL == "" {
+			options.URL = DEFAULT_OLLAMA_CHAT_URL
+		}
+		return &llm.OllamaChat{Options: options}, nil
+	case LLM_OPENAI:
+		if options.URL == "" {
+			options.URL = DEFAULT_OPENAI_URL
+		}
+		return &llm.OpenAI{Options: options, Strict: c.Strict}, nil
+	}
+	return nil, fmt.Errorf("unknown LLM provider %q", c.Provider)
+}
+
+// The answer schema is the built-in one unless SchemaFile is set.
+func loadAnswerSchema(schemaFile string) (json.RawMessage, error) {
+	if schemaFile == "" {
+		return prompt.Schema("answer")
+	}
+	schema, err := os.ReadFile(schemaFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading LLM schema: %w", err)
+	}
+	if !json.Valid(schema) {
+		return nil, fmt.Errorf("LLM schema %s is not valid JSON", schemaFile)
+	}
+	return schema, nil
+}
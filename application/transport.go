@@ -0,0 +1,40 @@
+package application
+
+import (
+	"fmt"
+
+	"github.com/Danny-Dasilva/CycleTLS/cycletls"
+	"github.com/rodatboat/go-vocab/cassette"
+)
+
+// Transport sends one request to vocabulary.com. cycletls.CycleTLS is the
+// real one; tests can swap Runner.Transport for a cassette.Replayer or a
+// stub.
+type Transport interface {
+	Do(url string, options cycletls.Options, method string) (cycletls.Response, error)
+}
+
+const (
+	CASSETTE_RECORD = "record"
+	CASSETTE_REPLAY = "replay"
+)
+
+var CASSETTE_MODES = []string{CASSETTE_RECORD, CASSETTE_REPLAY}
+
+// NewTransport is CycleTLS, recorded to the cassette at path in record mode.
+// Replay mode serves the cassette instead and never touches the network.
+func NewTransport(cassetteMode, cassettePath string) (Transport, error) {
+	switch cassetteMode {
+	case "":
+		return cycletls.Init(), nil
+	case CASSETTE_RECORD:
+		return cassette.NewRecorder(cassettePath, cycletls.Init()), nil
+	case CASSETTE_REPLAY:
+		replayer, err := cassette.NewReplayer(cassettePath)
+		if err != nil {
+			return nil, err
+		}
+		return replayer, nil
+	}
+	return nil, fmt.Errorf("unknown cassette mode %q", cassetteMode)
+}
@@ -0,0 +1,288 @@
+// Package cassette records the requests made to vocabulary.com and plays
+// them back, so a practice session can be run again without the site. Cookie
+// values and challenge secrets are never written to a cassette.
+package cassette
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/Danny-Dasilva/CycleTLS/cycletls"
+)
+
+// Transport is the request method of cycletls.CycleTLS, the same as
+// application.Transport. It is declared here too so the packages do not
+// import each other.
+type Transport interface {
+	Do(url string, options cycletls.Options, method string) (cycletls.Response, error)
+}
+
+// REDACTED replaces the value of every recorded cookie, and numbered it
+// replaces every challenge secret.
+const REDACTED = "REDACTED"
+
+// Headers that carry the session and are left out of a cassette.
+var scrubbedHeaders = []string{"Cookie", "Set-Cookie", "Authorization"}
+
+// The form key and JSON field of the challenge secret.
+const SECRET_KEY = "secret"
+
+// Form keys left out when a request body is matched, the response time is
+// random.
+var unmatchedFormKeys = []string{"rt"}
+
+var ErrExhausted = errors.New("cassette has no more interactions")
+
+// Cassette is the file format, one interaction per request in the order
+// they were made.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request leaves out the request headers, they are the same every time and
+// include the session cookies.
+type Request struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   string `json:"body,omitempty"`
+}
+
+type Response struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Cookies []Cookie          `json:"cookies,omitempty"`
+	Body    string            `json:"body"`
+}
+
+type Cookie struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func Load(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading cassette: %w", err)
+	}
+	var c Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("decoding cassette %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("writing cassette: %w", err)
+	}
+	return nil
+}
+
+// Recorder passes every request on to Next and appends it, with its
+// response, to the cassette at path. The file is rewritten after each
+// request so an interrupted session is still recorded.
+type Recorder struct {
+	Next Transport
+
+	path     string
+	mu       sync.Mutex
+	cassette Cassette
+	// Secrets seen so far and what they are recorded as, REDACTED-1,
+	// REDACTED-2... A request sending a secret back refers to the same
+	// placeholder as the response that gave it.
+	secrets map[string]string
+}
+
+func NewRecorder(path string, next Transport) *Recorder {
+	return &Recorder{Next: next, path: path, secrets: map[string]string{}}
+}
+
+func (r *Recorder) Do(url string, options cycletls.Options, method string) (cycletls.Response, error) {
+	resp, err := r.Next.Do(url, options, method)
+	if err != nil {
+		return resp, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request: Request{
+			Method: method,
+			URL:    url,
+			Body:   r.scrubForm(options.Body),
+		},
+		Response: r.scrub(resp),
+	})
+	if err := r.cassette.Save(r.path); err != nil {
+		return resp, err
+	}
+	return resp, nil
+}
+
+func (r *Recorder) scrub(resp cycletls.Response) Response {
+	recorded := Response{
+		Status: resp.Status,
+		Body:   r.scrubJson(resp.Body),
+	}
+	for name, value := range resp.Headers {
+		if isScrubbed(name) {
+			continue
+		}
+		if recorded.Headers == nil {
+			recorded.Headers = map[string]string{}
+		}
+		recorded.Headers[name] = value
+	}
+	for _, cookie := range resp.Cookies {
+		recorded.Cookies = append(recorded.Cookies, Cookie{Name: cookie.Name, Value: REDACTED})
+	}
+	return recorded
+}
+
+func (r *Recorder) placeholder(secret string) string {
+	if _, ok := r.secrets[secret]; !ok {
+		r.secrets[secret] = fmt.Sprintf("%s-%d", REDACTED, len(r.secrets)+1)
+	}
+	return r.secrets[secret]
+}
+
+// Replaces the secret of a form encoded body. Other bodies are kept as is.
+func (r *Recorder) scrubForm(body string) string {
+	form, err := url.ParseQuery(body)
+	if err != nil || !form.Has(SECRET_KEY) {
+		return body
+	}
+	for i, secret := range form[SECRET_KEY] {
+		if secret != "" {
+			form[SECRET_KEY][i] = r.placeholder(secret)
+		}
+	}
+	return form.Encode()
+}
+
+// Replaces the value of every secret field of a JSON body, wherever it
+// appears in the text, so the rest of the body is recorded byte for byte.
+func (r *Recorder) scrubJson(body string) string {
+	var decoded interface{}
+	if json.Unmarshal([]byte(body), &decoded) != nil {
+		return body
+	}
+	for _, secret := range secretValues(decoded) {
+		body = strings.ReplaceAll(body, secret, r.placeholder(secret))
+	}
+	return body
+}
+
+func secretValues(v interface{}) []string {
+	var secrets []string
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if secret, ok := value.(string); ok && strings.EqualFold(key, SECRET_KEY) && secret != "" {
+				secrets = append(secrets, secret)
+				continue
+			}
+			secrets = append(secrets, secretValues(value)...)
+		}
+	case []interface{}:
+		for _, value := range v {
+			secrets = append(secrets, secretValues(value)...)
+		}
+	}
+	return secrets
+}
+
+func isScrubbed(header string) bool {
+	for _, name := range scrubbedHeaders {
+		if strings.EqualFold(header, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Replayer serves the interactions of a cassette in order. Each request
+// must have the method, URL and body of the next recorded one. Responses
+// carry the placeholder secrets, so a session sends those back and its
+// bodies match the scrubbed ones; only the random response time is ignored.
+type Replayer struct {
+	mu           sync.Mutex
+	interactions []Interaction
+	next         int
+}
+
+func NewReplayer(path string) (*Replayer, error) {
+	c, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Replayer{interactions: c.Interactions}, nil
+}
+
+func (r *Replayer) Do(url string, options cycletls.Options, method string) (cycletls.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.next >= len(r.interactions) {
+		return cycletls.Response{}, fmt.Errorf("%w: %s %s", ErrExhausted, method, url)
+	}
+	recorded := r.interactions[r.next]
+	if recorded.Request.Method != method || recorded.Request.URL != url {
+		return cycletls.Response{}, fmt.Errorf("cassette interaction %d is %s %s, got %s %s",
+			r.next, recorded.Request.Method, recorded.Request.URL, method, url)
+	}
+	if !sameBody(recorded.Request.Body, options.Body) {
+		return cycletls.Response{}, fmt.Errorf("cassette interaction %d sent %q, got %q",
+			r.next, recorded.Request.Body, options.Body)
+	}
+	r.next++
+
+	resp := cycletls.Response{
+		Status:  recorded.Response.Status,
+		Body:    recorded.Response.Body,
+		Headers: recorded.Response.Headers,
+	}
+	for _, cookie := range recorded.Response.Cookies {
+		resp.Cookies = append(resp.Cookies, &http.Cookie{Name: cookie.Name, Value: cookie.Value})
+	}
+	return resp, nil
+}
+
+// Form bodies are compared by their values, without unmatchedFormKeys.
+func sameBody(recorded, sent string) bool {
+	want, err := url.ParseQuery(recorded)
+	if err != nil {
+		return recorded == sent
+	}
+	got, err := url.ParseQuery(sent)
+	if err != nil {
+		return false
+	}
+	for _, key := range unmatchedFormKeys {
+		want.Del(key)
+		got.Del(key)
+	}
+	return reflect.DeepEqual(want, got)
+}
+
+// Remaining is the number of interactions not played back yet.
+func (r *Replayer) Remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.interactions) - r.next
+}
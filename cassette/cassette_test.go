@@ -0,0 +1,92 @@
+package cassette
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Danny-Dasilva/CycleTLS/cycletls"
+)
+
+// site answers every request with the URL it was sent to, a new secret and a
+// new session cookie.
+type site struct{}
+
+func (site) Do(url string, options cycletls.Options, method string) (cycletls.Response, error) {
+	return cycletls.Response{
+		Status: 200,
+		Body:   `{"url":"` + url + `","secret":"live-` + path.Base(url) + `"}`,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+			"Set-Cookie":   "JSESSIONID=session-value; Path=/",
+		},
+		Cookies: []*http.Cookie{{Name: "JSESSIONID", Value: "session-value"}},
+	}, nil
+}
+
+func form(secret, rt string) cycletls.Options {
+	return cycletls.Options{
+		Body:    url.Values{"secret": {secret}, "rt": {rt}, "v": {"3"}}.Encode(),
+		Headers: map[string]string{"Cookie": "JSESSIONID=old-value"},
+		Cookies: []cycletls.Cookie{{Name: "JSESSIONID", Value: "old-value"}},
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	cassettePath := filepath.Join(t.TempDir(), "session.json")
+	recorder := NewRecorder(cassettePath, site{})
+	// Each request sends back the secret of the response before it.
+	if _, err := recorder.Do("https://example.com/a", form("live-start", "1200"), "POST"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := recorder.Do("https://example.com/b", form("live-a", "3400"), "POST"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(cassettePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, value := range []string{"session-value", "old-value", "live-"} {
+		if strings.Contains(string(data), value) {
+			t.Errorf("cassette contains %q:\n%s", value, data)
+		}
+	}
+
+	replayer, err := NewReplayer(cassettePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The response time is not matched.
+	resp, err := replayer.Do("https://example.com/a", form("REDACTED-1", "999"), "POST")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Body != `{"url":"https://example.com/a","secret":"REDACTED-2"}` || resp.Headers["Content-Type"] != "application/json" {
+		t.Errorf("replayed %+v", resp)
+	}
+	if len(resp.Cookies) != 1 || resp.Cookies[0].Name != "JSESSIONID" || resp.Cookies[0].Value != REDACTED {
+		t.Errorf("replayed cookies %v", resp.Cookies)
+	}
+
+	if _, err := replayer.Do("https://example.com/a", form("REDACTED-2", "999"), "POST"); err == nil {
+		t.Error("want an error for a request out of order")
+	}
+	if _, err := replayer.Do("https://example.com/b", form("REDACTED-1", "999"), "POST"); err == nil {
+		t.Error("want an error for a request with another secret")
+	}
+	if _, err := replayer.Do("https://example.com/b", form("REDACTED-2", "999"), "POST"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := replayer.Do("https://example.com/b", cycletls.Options{}, "POST"); !errors.Is(err, ErrExhausted) {
+		t.Errorf("want ErrExhausted, got %v", err)
+	}
+	if replayer.Remaining() != 0 {
+		t.Errorf("Remaining = %d", replayer.Remaining())
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+)
+
+const configUsage = `usage: go-vocab config show [flags]
+
+  show     print the effective config and where each value can be set,
+           with cookies and passwords masked
+
+Values are layered: defaults, then the config file (-config, GOVOCAB_CONFIG
+or go-vocab.yaml/.yml/.toml in the working directory), then GOVOCAB_*
+environment variables, then flags.
+`
+
+func runConfig(globals, args []string) int {
+	// config has a single subcommand, so its help is the help of show.
+	if len(args) > 0 && isHelpFlag(args[0]) {
+		args = append([]string{"show"}, args...)
+	}
+	if len(args) == 0 || args[0] != "show" {
+		fmt.Fprint(os.Stderr, configUsage)
+		return exitUsage
+	}
+
+	flags := newCommandFlags("config show", configUsage, globals)
+	if err := flags.Parse(args[1:]); err != nil {
+		return parseExitCode(err)
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return exitUsage
+	}
+	conf, err := flags.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "KEY\tVALUE\tENV\tFLAG")
+	for _, setting := range conf.Settings() {
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\n", setting.Key, setting.Value, setting.Env, setting.Flag)
+	}
+	table.Flush()
+
+	if err := conf.ValidateSession(); err != nil {
+		fmt.Printf("\nNot enough to practice yet: %v\n", err)
+	}
+	return exitOK
+}
@@ -0,0 +1,512 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/rodatboat/go-vocab/application"
+	"github.com/rodatboat/go-vocab/logging"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is everything the commands need to know about the account, the
+// store and the LLM. It is built in layers, each overriding the last:
+// defaults, a YAML or TOML file, GOVOCAB_* environment variables and flags.
+type Config struct {
+	ListId    int      `yaml:"list_id" toml:"list_id"`
+	Ja3       string   `yaml:"ja3" toml:"ja3"`
+	UserAgent string   `yaml:"user_agent" toml:"user_agent"`
+	Cookies   Cookies  `yaml:"cookies" toml:"cookies"`
+	Store     Store    `yaml:"store" toml:"store"`
+	LLM       LLM      `yaml:"llm" toml:"llm"`
+	MediaDir  string   `yaml:"media_dir" toml:"media_dir"`
+	LogLevel  string   `yaml:"log_level" toml:"log_level"`
+	LogFormat string   `yaml:"log_format" toml:"log_format"`
+	Cassette  Cassette `yaml:"cassette" toml:"cassette"`
+	Journal   string   `yaml:"journal" toml:"journal"`
+}
+
+// Cassette records the requests to vocabulary.com, or replays them instead
+// of going to the site.
+type Cassette struct {
+	Path string `yaml:"path" toml:"path"`
+	Mode string `yaml:"mode" toml:"mode"`
+}
+
+// Cookies of a logged in vocabulary.com session.
+type Cookies struct {
+	AWSALB     string `yaml:"awsalb" toml:"awsalb"`
+	JSessionId string `yaml:"jsessionid" toml:"jsessionid"`
+	Guid       string `yaml:"guid" toml:"guid"`
+}
+
+// Store is either a DSN, or the parts of a postgres connection.
+type Store struct {
+	DSN      string `yaml:"dsn" toml:"dsn"`
+	Host     string `yaml:"host" toml:"host"`
+	Port     string `yaml:"port" toml:"port"`
+	User     string `yaml:"user" toml:"user"`
+	Password string `yaml:"password" toml:"password"`
+	Name     string `yaml:"name" toml:"name"`
+}
+
+// LLM selects the model server that answers unseen questions. An empty URL
+// is the provider's usual local address.
+type LLM struct {
+	Provider    string        `yaml:"provider" toml:"provider"`
+	URL         string        `yaml:"url" toml:"url"`
+	Model       string        `yaml:"model" toml:"model"`
+	Temperature float64       `yaml:"temperature" toml:"temperature"`
+	Timeout     time.Duration `yaml:"timeout" toml:"timeout"`
+	APIKey      string        `yaml:"api_key" toml:"api_key"`
+	SchemaFile  string        `yaml:"schema_file" toml:"schema_file"`
+	PromptDir   string        `yaml:"prompt_dir" toml:"prompt_dir"`
+	Explain     bool          `yaml:"explain" toml:"explain"`
+	Strict      bool          `yaml:"strict" toml:"strict"`
+}
+
+// Files looked for in the working directory when no path is given.
+var defaultFiles = []string{"go-vocab.yaml", "go-vocab.yml", "go-vocab.toml"}
+
+func Default() *Config {
+	dbConfig := application.DefaultDBConfig()
+	return &Config{
+		UserAgent: application.DEFAULT_USER_AGENT,
+		Store: Store{
+			Host:     dbConfig.Host,
+			Port:     dbConfig.Port,
+			User:     dbConfig.User,
+			Password: dbConfig.Password,
+			Name:     dbConfig.DBName,
+		},
+		LLM: LLM{
+			Provider: application.LLM_OLLAMA_GENERATE,
+			Model:    application.DEFAULT_LLM_MODEL,
+			Timeout:  application.DEFAULT_LLM_TIMEOUT,
+		},
+		LogLevel:  "info",
+		LogFormat: logging.FORMAT_TEXT,
+	}
+}
+
+// setting ties one config value to its file key, environment variable and
+// flag, so the layers and `config show` cannot drift apart.
+type setting struct {
+	key    string
+	flag   string
+	usage  string
+	secret bool
+	// Boolean flags can be given without a value.
+	boolean bool
+	get     func(c *Config) string
+	set     func(c *Config, value string) error
+}
+
+func (s setting) env() string {
+	return "GOVOCAB_" + strings.ToUpper(strings.ReplaceAll(s.key, ".", "_"))
+}
+
+func stringSetting(key, flag, usage string, secret bool, field func(c *Config) *string) setting {
+	return setting{
+		key:    key,
+		flag:   flag,
+		usage:  usage,
+		secret: secret,
+		get:    func(c *Config) string { return *field(c) },
+		set: func(c *Config, value string) error {
+			*field(c) = value
+			return nil
+		},
+	}
+}
+
+func boolSetting(key, flag, usage string, field func(c *Config) *bool) setting {
+	return setting{
+		key:     key,
+		flag:    flag,
+		usage:   usage,
+		boolean: true,
+		get:     func(c *Config) string { return strconv.FormatBool(*field(c)) },
+		set: func(c *Config, value string) error {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("not a boolean: %q", value)
+			}
+			*field(c) = parsed
+			return nil
+		},
+	}
+}
+
+var settings = []setting{
+	{
+		key:   "list_id",
+		flag:  "list",
+		usage: "id of the word list to practice",
+		get: func(c *Config) string {
+			return strconv.Itoa(c.ListId)
+		},
+		set: func(c *Config, value string) error {
+			listId, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("not a number: %q", value)
+			}
+			c.ListId = listId
+			return nil
+		},
+	},
+	stringSetting("ja3", "ja3", "JA3 fingerprint of the TLS client", false, func(c *Config) *string { return &c.Ja3 }),
+	stringSetting("user_agent", "user-agent", "User-Agent header sent to vocabulary.com", false, func(c *Config) *string { return &c.UserAgent }),
+	stringSetting("cookies.awsalb", "awsalb", "AWSALB cookie", true, func(c *Config) *string { return &c.Cookies.AWSALB }),
+	stringSetting("cookies.jsessionid", "jsessionid", "JSESSIONID cookie", true, func(c *Config) *string { return &c.Cookies.JSessionId }),
+	stringSetting("cookies.guid", "guid", "guid cookie", true, func(c *Config) *string { return &c.Cookies.Guid }),
+	stringSetting("store.dsn", "dsn", "store DSN (postgres://, sqlite://, memory://), overrides the db-* settings", true, func(c *Config) *string { return &c.Store.DSN }),
+	stringSetting("store.host", "db-host", "postgres host", false, func(c *Config) *string { return &c.Store.Host }),
+	stringSetting("store.port", "db-port", "postgres port", false, func(c *Config) *string { return &c.Store.Port }),
+	stringSetting("store.user", "db-user", "postgres user", false, func(c *Config) *string { return &c.Store.User }),
+	stringSetting("store.password", "db-password", "postgres password", true, func(c *Config) *string { return &c.Store.Password }),
+	stringSetting("store.name", "db-name", "postgres database name", false, func(c *Config) *string { return &c.Store.Name }),
+	stringSetting("llm.provider", "llm", strings.Join(application.LLM_PROVIDERS, ", "), false, func(c *Config) *string { return &c.LLM.Provider }),
+	stringSetting("llm.url", "llm-url", "LLM endpoint, defaults to the provider's local address", false, func(c *Config) *string { return &c.LLM.URL }),
+	stringSetting("llm.model", "llm-model", "LLM model name", false, func(c *Config) *string { return &c.LLM.Model }),
+	{
+		key:   "llm.temperature",
+		flag:  "llm-temperature",
+		usage: "LLM sampling temperature",
+		get: func(c *Config) string {
+			return strconv.FormatFloat(c.LLM.Temperature, 'g', -1, 64)
+		},
+		set: func(c *Config, value string) error {
+			temperature, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("not a number: %q", value)
+			}
+			c.LLM.Temperature = temperature
+			return nil
+		},
+	},
+	{
+		key:   "llm.timeout",
+		flag:  "llm-timeout",
+		usage: "how long to wait for one LLM reply, e.g. 90s",
+		get: func(c *Config) string {
+			return c.LLM.Timeout.String()
+		},
+		set: func(c *Config, value string) error {
+			timeout, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("not a duration: %q", value)
+			}
+			c.LLM.Timeout = timeout
+			return nil
+		},
+	},
+	stringSetting("llm.api_key", "llm-api-key", "bearer token for the LLM endpoint", true, func(c *Config) *string { return &c.LLM.APIKey }),
+	stringSetting("llm.schema_file", "llm-schema", "JSON schema file for answers, instead of the built-in one", false, func(c *Config) *string { return &c.LLM.SchemaFile }),
+	boolSetting("llm.explain", "explain", "store an LLM explanation of every question answered correctly", func(c *Config) *bool { return &c.LLM.Explain }),
+	boolSetting("llm.strict", "llm-strict", "use the strict json_schema mode of the openai provider", func(c *Config) *bool { return &c.LLM.Strict }),
+	stringSetting("llm.prompt_dir", "prompt-dir", "directory of prompt template overrides, optionally per question type", false, func(c *Config) *string { return &c.LLM.PromptDir }),
+	stringSetting("media_dir", "media-dir", "download image choices into this directory", false, func(c *Config) *string { return &c.MediaDir }),
+	stringSetting("log_level", "log-level", "debug, info, warn or error", false, func(c *Config) *string { return &c.LogLevel }),
+	stringSetting("log_format", "log-format", strings.Join(logging.FORMATS, " or ")+" logs on stderr", false, func(c *Config) *string { return &c.LogFormat }),
+	stringSetting("cassette.path", "cassette", "cassette file of recorded requests", false, func(c *Config) *string { return &c.Cassette.Path }),
+	stringSetting("cassette.mode", "cassette-mode", strings.Join(application.CASSETTE_MODES, " or ")+" the cassette", false, func(c *Config) *string { return &c.Cassette.Mode }),
+	stringSetting("journal", "journal", "append every practice event to this JSONL file", false, func(c *Config) *string { return &c.Journal }),
+}
+
+// Flags collects config overrides from the command line. Values are only
+// applied by Load, after the file and environment, so a flag always wins.
+type Flags struct {
+	path      string
+	overrides []func(c *Config) error
+}
+
+// RegisterFlags adds -config and a flag per setting to flags.
+func RegisterFlags(flags *flag.FlagSet) *Flags {
+	f := &Flags{}
+	flags.StringVar(&f.path, "config", os.Getenv("GOVOCAB_CONFIG"), "config file (.yaml, .yml or .toml)")
+	for _, s := range settings {
+		s := s
+		override := func(value string) error {
+			f.overrides = append(f.overrides, func(c *Config) error {
+				if err := s.set(c, value); err != nil {
+					return fmt.Errorf("-%s: %w", s.flag, err)
+				}
+				return nil
+			})
+			return nil
+		}
+		if s.boolean {
+			flags.BoolFunc(s.flag, s.usage, override)
+		} else {
+			flags.Func(s.flag, s.usage, override)
+		}
+	}
+	return f
+}
+
+// Load builds the config from all layers and validates it.
+func (f *Flags) Load() (*Config, error) {
+	config, err := load(f.path, os.LookupEnv)
+	if err != nil {
+		return nil, err
+	}
+	for _, override := range f.overrides {
+		if err := override(config); err != nil {
+			return nil, err
+		}
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func load(path string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	config := Default()
+
+	if path == "" {
+		for _, name := range defaultFiles {
+			if _, err := os.Stat(name); err == nil {
+				path = name
+				break
+			}
+		}
+	}
+	if path != "" {
+		if err := config.readFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, s := range settings {
+		value, ok := lookupEnv(s.env())
+		if !ok {
+			continue
+		}
+		if err := s.set(config, value); err != nil {
+			return nil, fmt.Errorf("%s: %w", s.env(), err)
+		}
+	}
+	return config, nil
+}
+
+func (c *Config) readFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(strings.NewReader(string(data)))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("config %s: %w", path, err)
+		}
+	case ".toml":
+		meta, err := toml.Decode(string(data), c)
+		if err != nil {
+			return fmt.Errorf("config %s: %w", path, err)
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("config %s: unknown key %q", path, undecoded[0].String())
+		}
+	default:
+		return fmt.Errorf("config %s: unsupported format, use .yaml, .yml or .toml", path)
+	}
+	return nil
+}
+
+// Validate checks the values that are wrong whatever the command. Settings
+// only some commands need are checked by ValidateSession and ValidateLogin.
+func (c *Config) Validate() error {
+	var errs []error
+	if c.ListId < 0 {
+		errs = append(errs, fmt.Errorf("list_id must be positive, got %d", c.ListId))
+	}
+	if c.Store.DSN != "" {
+		if _, _, ok := strings.Cut(c.Store.DSN, "://"); !ok {
+			errs = append(errs, fmt.Errorf("store.dsn must start with a scheme such as sqlite:// or postgres://"))
+		}
+	} else if _, err := strconv.Atoi(c.Store.Port); err != nil {
+		errs = append(errs, fmt.Errorf("store.port must be a number, got %q", c.Store.Port))
+	}
+	if !slices.Contains(application.LLM_PROVIDERS, c.LLM.Provider) {
+		errs = append(errs, fmt.Errorf("llm.provider must be one of %s, got %q", strings.Join(application.LLM_PROVIDERS, ", "), c.LLM.Provider))
+	}
+	if c.LLM.URL != "" {
+		if parsed, err := url.Parse(c.LLM.URL); err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			errs = append(errs, fmt.Errorf("llm.url must be an absolute URL, got %q", c.LLM.URL))
+		}
+	}
+	if c.LLM.Temperature < 0 || c.LLM.Temperature > 2 {
+		errs = append(errs, fmt.Errorf("llm.temperature must be between 0 and 2, got %g", c.LLM.Temperature))
+	}
+	if c.LLM.Timeout <= 0 {
+		errs = append(errs, fmt.Errorf("llm.timeout must be positive, got %s", c.LLM.Timeout))
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.LogLevel)); err != nil {
+		errs = append(errs, fmt.Errorf("log_level must be debug, info, warn or error, got %q", c.LogLevel))
+	}
+	if !slices.Contains(logging.FORMATS, c.LogFormat) {
+		errs = append(errs, fmt.Errorf("log_format must be one of %s, got %q", strings.Join(logging.FORMATS, ", "), c.LogFormat))
+	}
+	if c.Cassette.Mode != "" {
+		if !slices.Contains(application.CASSETTE_MODES, c.Cassette.Mode) {
+			errs = append(errs, fmt.Errorf("cassette.mode must be one of %s, got %q", strings.Join(application.CASSETTE_MODES, ", "), c.Cassette.Mode))
+		} else if c.Cassette.Path == "" {
+			errs = append(errs, fmt.Errorf("cassette.path is needed to %s a cassette", c.Cassette.Mode))
+		}
+	}
+	return joinErrors(errs)
+}
+
+// ValidateSession checks the settings needed to talk to vocabulary.com.
+// Replaying a cassette only needs the list.
+func (c *Config) ValidateSession() error {
+	sessionKeys := []string{"list_id", "ja3", "cookies.awsalb", "cookies.jsessionid", "cookies.guid"}
+	if c.Cassette.Mode == application.CASSETTE_REPLAY {
+		sessionKeys = sessionKeys[:1]
+	}
+	return c.requireKeys(sessionKeys)
+}
+
+// ValidateLogin checks the settings needed to be logged in on vocabulary.com,
+// without a list.
+func (c *Config) ValidateLogin() error {
+	if c.Cassette.Mode == application.CASSETTE_REPLAY {
+		return nil
+	}
+	return c.requireKeys([]string{"ja3", "cookies.awsalb", "cookies.jsessionid", "cookies.guid"})
+}
+
+func (c *Config) requireKeys(sessionKeys []string) error {
+	var errs []error
+	for _, s := range settings {
+		if slices.Contains(sessionKeys, s.key) {
+			if value := s.get(c); value == "" || value == "0" {
+				errs = append(errs, fmt.Errorf("%s is not set (config file, %s or -%s)", s.key, s.env(), s.flag))
+			}
+		}
+	}
+	return joinErrors(errs)
+}
+
+func joinErrors(errs []error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid config: %w", errors.Join(errs...))
+}
+
+func (c *Config) DBConfig() application.RunDBConfig {
+	return application.RunDBConfig{
+		DBName:   c.Store.Name,
+		Host:     c.Store.Host,
+		Port:     c.Store.Port,
+		User:     c.Store.User,
+		Password: c.Store.Password,
+	}
+}
+
+// StoreDSN is store.dsn when set, otherwise the postgres database.
+func (c *Config) StoreDSN() string {
+	if c.Store.DSN != "" {
+		return c.Store.DSN
+	}
+	return c.DBConfig().DSN()
+}
+
+// SlogLevel is log_level as a slog.Level, info if it does not parse.
+func (c *Config) SlogLevel() slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.LogLevel)); err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
+
+func (c *Config) RunParams() application.RunParams {
+	return application.RunParams{
+		ListId:       c.ListId,
+		AlbCookie:    c.Cookies.AWSALB,
+		JSessionId:   c.Cookies.JSessionId,
+		Guid:         c.Cookies.Guid,
+		Ja3:          c.Ja3,
+		StoreDSN:     c.StoreDSN(),
+		MediaDir:     c.MediaDir,
+		DBConfig:     c.DBConfig(),
+		UserAgent:    c.UserAgent,
+		Cassette:     c.Cassette.Path,
+		CassetteMode: c.Cassette.Mode,
+		Journal:      c.Journal,
+		LLM: application.LLMConfig{
+			Provider:    c.LLM.Provider,
+			URL:         c.LLM.URL,
+			Model:       c.LLM.Model,
+			Temperature: c.LLM.Temperature,
+			Timeout:     c.LLM.Timeout,
+			APIKey:      c.LLM.APIKey,
+			SchemaFile:  c.LLM.SchemaFile,
+			PromptDir:   c.LLM.PromptDir,
+			Explain:     c.LLM.Explain,
+			Strict:      c.LLM.Strict,
+		},
+	}
+}
+
+// Setting is one line of `config show`.
+type Setting struct {
+	Key   string
+	Env   string
+	Flag  string
+	Value string
+}
+
+// Settings lists every value with secrets masked.
+func (c *Config) Settings() []Setting {
+	shown := make([]Setting, 0, len(settings))
+	for _, s := range settings {
+		value := s.get(c)
+		if s.secret {
+			value = mask(s.key, value)
+		}
+		shown = append(shown, Setting{Key: s.key, Env: s.env(), Flag: "-" + s.flag, Value: value})
+	}
+	return shown
+}
+
+// A URL DSN with userinfo only has its passwords hidden, so the host and
+// database stay visible. Any other DSN, e.g. libpq's key=value form, is
+// hidden whole.
+func mask(key, value string) string {
+	if value == "" {
+		return ""
+	}
+	if key == "store.dsn" {
+		parsed, err := url.Parse(value)
+		if err != nil || parsed.User == nil {
+			return "****"
+		}
+		query := parsed.Query()
+		if query.Has("password") {
+			query.Set("password", "xxxxx")
+			parsed.RawQuery = query.Encode()
+		}
+		return parsed.Redacted()
+	}
+	return "****"
+}
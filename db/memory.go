@@ -0,0 +1,423 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rodatboat/go-vocab/model"
+)
+
+// MemoryStore keeps everything in process, for tests and dry runs.
+type MemoryStore struct {
+	mu          sync.Mutex
+	nextId      int
+	nextWordId  int
+	questions   []model.Question
+	index       map[questionKey]int
+	attempts    []model.Attempt
+	words       map[string]*model.Word
+	reviews     map[int]model.Review
+	snapshots   []model.AccountSnapshot
+	progress    []model.ProgressSnapshot
+	explained   map[int]model.Explanation
+	checkpoints map[int]model.Checkpoint
+}
+
+type questionKey struct {
+	questionType    string
+	questionContext string
+	question        string
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		nextId:      1,
+		nextWordId:  1,
+		index:       map[questionKey]int{},
+		words:       map[string]*model.Word{},
+		reviews:     map[int]model.Review{},
+		explained:   map[int]model.Explanation{},
+		checkpoints: map[int]model.Checkpoint{},
+	}
+}
+
+func (s *MemoryStore) SaveQuestion(ctx context.Context, question model.Question) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := questionKey{question.QuestionType, question.QuestionContext, question.Question}
+	if i, ok := s.index[key]; ok {
+		existing := &s.questions[i]
+		if !existing.IsCorrect {
+			existing.Answer = question.Answer
+			existing.AnswerKey = question.AnswerKey
+			existing.IsCorrect = question.IsCorrect
+			existing.TargetWord = question.TargetWord
+		}
+		if len(existing.Choices) == 0 {
+			existing.Choices = append([]model.QuestionChoices(nil), question.Choices...)
+		}
+		s.linkWord(existing, question.TargetWord, answered(question))
+		return existing.ID, nil
+	}
+
+	question.ID = s.nextId
+	question.WordID = 0
+	s.nextId++
+	question.Choices = append([]model.QuestionChoices(nil), question.Choices...)
+	s.index[key] = len(s.questions)
+	s.questions = append(s.questions, question)
+	s.linkWord(&s.questions[len(s.questions)-1], question.TargetWord, answered(question))
+	return question.ID, nil
+}
+
+func (s *MemoryStore) linkWord(question *model.Question, targetWord string, replace bool) {
+	lemma := normalizeLemma(targetWord)
+	if lemma == "" {
+		return
+	}
+
+	now := time.Now().UTC()
+	if question.WordID != 0 && !replace {
+		for _, word := range s.words {
+			if word.ID == question.WordID {
+				word.LastSeen = now
+			}
+		}
+		return
+	}
+	word, ok := s.words[lemma]
+	if !ok {
+		word = &model.Word{
+			ID:        s.nextWordId,
+			Lemma:     lemma,
+			FirstSeen: now,
+			Mastery:   masteryEstimate(0, 0),
+		}
+		s.nextWordId++
+		s.words[lemma] = word
+	}
+	word.LastSeen = now
+	if question.WordID == word.ID {
+		return
+	}
+	previous := question.WordID
+	question.WordID = word.ID
+	if previous != 0 {
+		s.dropUnusedWord(previous)
+	}
+}
+
+// Drops a word nothing refers to, once a question is relinked away from it.
+func (s *MemoryStore) dropUnusedWord(wordId int) {
+	for _, question := range s.questions {
+		if question.WordID == wordId {
+			return
+		}
+	}
+	if _, ok := s.reviews[wordId]; ok {
+		return
+	}
+	for lemma, word := range s.words {
+		if word.ID == wordId && word.TimesAsked == 0 {
+			delete(s.words, lemma)
+		}
+	}
+}
+
+func (s *MemoryStore) GetQuestion(ctx context.Context, id int) (*model.Question, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, question := range s.questions {
+		if question.ID == id {
+			return &question, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) FindQuestion(ctx context.Context, questionType, questionContext, question string) (*model.Question, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.index[questionKey{questionType, questionContext, question}]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	found := s.questions[i]
+	return &found, nil
+}
+
+func (s *MemoryStore) ListQuestions(ctx context.Context, filter QuestionFilter) ([]model.Question, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var questions []model.Question
+	for _, question := range s.questions {
+		if !matchesFilter(question, filter) {
+			continue
+		}
+		questions = append(questions, question)
+		if filter.Limit > 0 && len(questions) == filter.Limit {
+			break
+		}
+	}
+	return questions, nil
+}
+
+func (s *MemoryStore) SaveAttempt(ctx context.Context, attempt model.Attempt) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var question *model.Question
+	for i := range s.questions {
+		if s.questions[i].ID == attempt.QuestionID {
+			question = &s.questions[i]
+			break
+		}
+	}
+	if question == nil {
+		return 0, fmt.Errorf("attempt for question %d: %w", attempt.QuestionID, ErrNotFound)
+	}
+
+	if attempt.CreatedAt.IsZero() {
+		attempt.CreatedAt = time.Now().UTC()
+	}
+	for _, word := range s.words {
+		if word.ID != question.WordID {
+			continue
+		}
+		word.TimesAsked++
+		if attempt.IsCorrect {
+			word.TimesCorrect++
+		}
+		word.Mastery = masteryEstimate(word.TimesAsked, word.TimesCorrect)
+		word.LastSeen = attempt.CreatedAt
+	}
+	attempt.ID = len(s.attempts) + 1
+	s.attempts = append(s.attempts, attempt)
+	return attempt.ID, nil
+}
+
+func (s *MemoryStore) ListAttempts(ctx context.Context, filter AttemptFilter) ([]model.Attempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var attempts []model.Attempt
+	for _, attempt := range s.attempts {
+		if !matchesAttemptFilter(attempt, filter) {
+			continue
+		}
+		attempts = append(attempts, attempt)
+		if filter.Limit > 0 && len(attempts) == filter.Limit {
+			break
+		}
+	}
+	return attempts, nil
+}
+
+func (s *MemoryStore) GetWord(ctx context.Context, lemma string) (*model.Word, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	word, ok := s.words[normalizeLemma(lemma)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	found := *word
+	return &found, nil
+}
+
+func (s *MemoryStore) ListWords(ctx context.Context) ([]model.Word, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	words := make([]model.Word, 0, len(s.words))
+	for _, word := range s.words {
+		words = append(words, *word)
+	}
+	sort.Slice(words, func(i, j int) bool {
+		return words[i].Lemma < words[j].Lemma
+	})
+	return words, nil
+}
+
+func (s *MemoryStore) SaveReview(ctx context.Context, review model.Review) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, word := range s.words {
+		if word.ID == review.WordID {
+			s.reviews[review.WordID] = review
+			return nil
+		}
+	}
+	return fmt.Errorf("review for word %d: %w", review.WordID, ErrNotFound)
+}
+
+func (s *MemoryStore) ListReviews(ctx context.Context) ([]model.Review, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reviews := make([]model.Review, 0, len(s.reviews))
+	for _, review := range s.reviews {
+		reviews = append(reviews, review)
+	}
+	sort.Slice(reviews, func(i, j int) bool {
+		if !reviews[i].DueAt.Equal(reviews[j].DueAt) {
+			return reviews[i].DueAt.Before(reviews[j].DueAt)
+		}
+		return reviews[i].WordID < reviews[j].WordID
+	})
+	return reviews, nil
+}
+
+func (s *MemoryStore) SaveExplanation(ctx context.Context, explanation model.Explanation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, question := range s.questions {
+		if question.ID == explanation.QuestionID {
+			s.explained[explanation.QuestionID] = explanation
+			return nil
+		}
+	}
+	return fmt.Errorf("explanation for question %d: %w", explanation.QuestionID, ErrNotFound)
+}
+
+func (s *MemoryStore) GetExplanation(ctx context.Context, questionId int) (*model.Explanation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	explanation, ok := s.explained[questionId]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &explanation, nil
+}
+
+func (s *MemoryStore) ListExplanations(ctx context.Context) ([]model.Explanation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	explanations := make([]model.Explanation, 0, len(s.explained))
+	for _, explanation := range s.explained {
+		explanations = append(explanations, explanation)
+	}
+	sort.Slice(explanations, func(i, j int) bool {
+		return explanations[i].QuestionID < explanations[j].QuestionID
+	})
+	return explanations, nil
+}
+
+func (s *MemoryStore) SaveCheckpoint(ctx context.Context, checkpoint model.Checkpoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if checkpoint.SavedAt.IsZero() {
+		checkpoint.SavedAt = time.Now().UTC()
+	}
+	s.checkpoints[checkpoint.ListId] = checkpoint
+	return nil
+}
+
+func (s *MemoryStore) GetCheckpoint(ctx context.Context, listId int) (*model.Checkpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	checkpoint, ok := s.checkpoints[listId]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &checkpoint, nil
+}
+
+func (s *MemoryStore) SaveAccountSnapshot(ctx context.Context, snapshot model.AccountSnapshot) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if snapshot.TakenAt.IsZero() {
+		snapshot.TakenAt = time.Now().UTC()
+	}
+	snapshot.ID = len(s.snapshots) + 1
+	snapshot.Lists = append([]model.WordList(nil), snapshot.Lists...)
+	s.snapshots = append(s.snapshots, snapshot)
+	return snapshot.ID, nil
+}
+
+func (s *MemoryStore) ListAccountSnapshots(ctx context.Context) ([]model.AccountSnapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshots := append([]model.AccountSnapshot(nil), s.snapshots...)
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].TakenAt.Before(snapshots[j].TakenAt)
+	})
+	return snapshots, nil
+}
+
+func (s *MemoryStore) SaveProgressSnapshot(ctx context.Context, snapshot model.ProgressSnapshot) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if snapshot.TakenAt.IsZero() {
+		snapshot.TakenAt = time.Now().UTC()
+	}
+	snapshot.ID = len(s.progress) + 1
+	s.progress = append(s.progress, snapshot)
+	return snapshot.ID, nil
+}
+
+func (s *MemoryStore) ListProgressSnapshots(ctx context.Context, filter ProgressFilter) ([]model.ProgressSnapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var snapshots []model.ProgressSnapshot
+	for _, snapshot := range s.progress {
+		if matchesProgressFilter(snapshot, filter) {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].TakenAt.Before(snapshots[j].TakenAt)
+	})
+	if filter.Limit > 0 && len(snapshots) > filter.Limit {
+		snapshots = snapshots[:filter.Limit]
+	}
+	return snapshots, nil
+}
+
+func (s *MemoryStore) Stats(ctx context.Context) (*model.QuestionStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := &model.QuestionStats{ByType: map[string]model.QuestionTypeStats{}}
+	for _, question := range s.questions {
+		typeStats := stats.ByType[question.QuestionType]
+		typeStats.Total++
+		stats.Total++
+		if question.Answer != "" {
+			stats.Answered++
+		}
+		if question.IsCorrect {
+			typeStats.Correct++
+			stats.Correct++
+		}
+		stats.ByType[question.QuestionType] = typeStats
+	}
+	for _, attempt := range s.attempts {
+		stats.Attempts++
+		if attempt.IsCorrect {
+			stats.CorrectAttempts++
+		}
+	}
+	return stats, nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
@@ -0,0 +1,192 @@
+package db
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Migrations live in migrations/<dialect>/NNNN_name.up.sql, with an
+// optional matching NNNN_name.down.sql.
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrator is implemented by the stores that keep a versioned schema.
+type Migrator interface {
+	MigrateUp(ctx context.Context) ([]Migration, error)
+	// Rolls back the last `steps` applied migrations.
+	MigrateDown(ctx context.Context, steps int) ([]Migration, error)
+	MigrationStatus(ctx context.Context) ([]MigrationStatus, error)
+}
+
+// migrationTarget is what each backend provides to the shared migration logic.
+type migrationTarget interface {
+	ensureMigrationsTable(ctx context.Context) error
+	appliedMigrations(ctx context.Context) (map[int]time.Time, error)
+	// Runs the script and records (or removes) the version in one transaction.
+	applyMigration(ctx context.Context, migration Migration, up bool) error
+}
+
+func loadMigrations(dialect string) ([]Migration, error) {
+	dir := path.Join("migrations", dialect)
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s migrations: %w", dialect, err)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		fileName := entry.Name()
+		var up bool
+		var base string
+		switch {
+		case strings.HasSuffix(fileName, ".up.sql"):
+			up, base = true, strings.TrimSuffix(fileName, ".up.sql")
+		case strings.HasSuffix(fileName, ".down.sql"):
+			base = strings.TrimSuffix(fileName, ".down.sql")
+		default:
+			continue
+		}
+
+		versionStr, name, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s: expected NNNN_name", fileName)
+		}
+		version, err := strconv.Atoi(versionStr)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: bad version: %w", fileName, err)
+		}
+
+		script, err := fs.ReadFile(migrationFiles, path.Join(dir, fileName))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		}
+		if up {
+			migration.Up = string(script)
+		} else {
+			migration.Down = string(script)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %04d_%s has no up script", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+func migrateUp(ctx context.Context, target migrationTarget, dialect string) ([]Migration, error) {
+	migrations, err := loadMigrations(dialect)
+	if err != nil {
+		return nil, err
+	}
+	if err := target.ensureMigrationsTable(ctx); err != nil {
+		return nil, fmt.Errorf("creating schema_migrations: %w", err)
+	}
+	applied, err := target.appliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var ran []Migration
+	for _, migration := range migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		if err := target.applyMigration(ctx, migration, true); err != nil {
+			return ran, fmt.Errorf("migration %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+		ran = append(ran, migration)
+	}
+	return ran, nil
+}
+
+func migrateDown(ctx context.Context, target migrationTarget, dialect string, steps int) ([]Migration, error) {
+	migrations, err := loadMigrations(dialect)
+	if err != nil {
+		return nil, err
+	}
+	if err := target.ensureMigrationsTable(ctx); err != nil {
+		return nil, fmt.Errorf("creating schema_migrations: %w", err)
+	}
+	applied, err := target.appliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var ran []Migration
+	for i := len(migrations) - 1; i >= 0 && len(ran) < steps; i-- {
+		migration := migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if migration.Down == "" {
+			return ran, fmt.Errorf("migration %04d_%s has no down script", migration.Version, migration.Name)
+		}
+		if err := target.applyMigration(ctx, migration, false); err != nil {
+			return ran, fmt.Errorf("migration %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+		ran = append(ran, migration)
+	}
+	return ran, nil
+}
+
+func migrationStatus(ctx context.Context, target migrationTarget, dialect string) ([]MigrationStatus, error) {
+	migrations, err := loadMigrations(dialect)
+	if err != nil {
+		return nil, err
+	}
+	if err := target.ensureMigrationsTable(ctx); err != nil {
+		return nil, fmt.Errorf("creating schema_migrations: %w", err)
+	}
+	applied, err := target.appliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, migration := range migrations {
+		appliedAt, ok := applied[migration.Version]
+		statuses = append(statuses, MigrationStatus{
+			Migration: migration,
+			Applied:   ok,
+			AppliedAt: appliedAt,
+		})
+	}
+	return statuses, nil
+}
+
+var ErrNoMigrations = errors.New("store does not use migrations")
@@ -0,0 +1,81 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestDialectsHaveSameMigrations(t *testing.T) {
+	postgres, err := loadMigrations("postgres")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlite, err := loadMigrations("sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(postgres) != len(sqlite) {
+		t.Fatalf("postgres has %d migrations, sqlite has %d", len(postgres), len(sqlite))
+	}
+	for i := range postgres {
+		if postgres[i].Version != sqlite[i].Version || postgres[i].Name != sqlite[i].Name {
+			t.Errorf("migration %d differs: %04d_%s vs %04d_%s", i,
+				postgres[i].Version, postgres[i].Name, sqlite[i].Version, sqlite[i].Name)
+		}
+		if postgres[i].Down == "" || sqlite[i].Down == "" {
+			t.Errorf("migration %04d_%s is missing a down script", postgres[i].Version, postgres[i].Name)
+		}
+	}
+}
+
+func TestSQLiteMigrateUpDown(t *testing.T) {
+	ctx := context.Background()
+	store, err := OpenUnmigrated(ctx, "sqlite://"+filepath.Join(t.TempDir(), "vocab.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	migrator := store.(Migrator)
+
+	all, err := loadMigrations("sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ran, err := migrator.MigrateUp(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ran) != len(all) {
+		t.Errorf("first MigrateUp ran %d migrations, want %d", len(ran), len(all))
+	}
+	ran, err = migrator.MigrateUp(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ran) != 0 {
+		t.Errorf("second MigrateUp ran %d migrations, want 0", len(ran))
+	}
+
+	statuses, err := migrator.MigrationStatus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range statuses {
+		if !status.Applied || status.AppliedAt.IsZero() {
+			t.Errorf("migration %04d_%s not recorded as applied", status.Version, status.Name)
+		}
+	}
+
+	ran, err = migrator.MigrateDown(ctx, len(all))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ran) != len(all) {
+		t.Errorf("MigrateDown rolled back %d migrations, want %d", len(ran), len(all))
+	}
+	if _, err := store.Stats(ctx); err == nil {
+		t.Error("question table still exists after rolling everything back")
+	}
+}
@@ -0,0 +1,2 @@
+ALTER TABLE question DROP COLUMN IF EXISTS word_id;
+DROP TABLE IF EXISTS word;
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS word (
+    id SERIAL PRIMARY KEY,
+    lemma VARCHAR(255) NOT NULL UNIQUE,
+    first_seen TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    times_asked INTEGER NOT NULL DEFAULT 0,
+    times_correct INTEGER NOT NULL DEFAULT 0,
+    -- (times_correct + 1) / (times_asked + 2), so unseen words start at 0.5
+    mastery DOUBLE PRECISION NOT NULL DEFAULT 0.5
+);
+
+ALTER TABLE question ADD COLUMN IF NOT EXISTS word_id INTEGER REFERENCES word (id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS question_word_id_idx ON question (word_id);
+
+INSERT INTO word (lemma)
+SELECT DISTINCT LOWER(TRIM(target_word)) FROM question
+WHERE target_word IS NOT NULL AND TRIM(target_word) <> ''
+ON CONFLICT (lemma) DO NOTHING;
+
+UPDATE question SET word_id = word.id
+FROM word
+WHERE word.lemma = LOWER(TRIM(question.target_word));
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS review (
+    word_id INTEGER PRIMARY KEY REFERENCES word (id) ON DELETE CASCADE,
+    ease DOUBLE PRECISION NOT NULL DEFAULT 2.5,
+    interval_days INTEGER NOT NULL DEFAULT 0,
+    repetitions INTEGER NOT NULL DEFAULT 0,
+    due_at TIMESTAMPTZ NOT NULL,
+    last_reviewed_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS review_due_at_idx ON review (due_at);
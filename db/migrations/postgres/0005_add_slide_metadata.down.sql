@@ -0,0 +1,7 @@
+ALTER TABLE question DROP COLUMN IF EXISTS audio_id;
+ALTER TABLE question DROP COLUMN IF EXISTS slide_template;
+ALTER TABLE question DROP COLUMN IF EXISTS slide_type;
+ALTER TABLE question DROP COLUMN IF EXISTS source_title;
+ALTER TABLE question DROP COLUMN IF EXISTS source_url;
+ALTER TABLE question DROP COLUMN IF EXISTS point_value;
+ALTER TABLE question DROP COLUMN IF EXISTS slide_mode;
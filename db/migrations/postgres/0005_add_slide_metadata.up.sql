@@ -0,0 +1,7 @@
+ALTER TABLE question ADD COLUMN IF NOT EXISTS slide_mode VARCHAR(32);
+ALTER TABLE question ADD COLUMN IF NOT EXISTS point_value INTEGER;
+ALTER TABLE question ADD COLUMN IF NOT EXISTS source_url TEXT;
+ALTER TABLE question ADD COLUMN IF NOT EXISTS source_title TEXT;
+ALTER TABLE question ADD COLUMN IF NOT EXISTS slide_type VARCHAR(32);
+ALTER TABLE question ADD COLUMN IF NOT EXISTS slide_template VARCHAR(64);
+ALTER TABLE question ADD COLUMN IF NOT EXISTS audio_id VARCHAR(64);
@@ -0,0 +1,5 @@
+ALTER TABLE question DROP COLUMN IF EXISTS correct_rate;
+ALTER TABLE question DROP COLUMN IF EXISTS answer_stats_total;
+ALTER TABLE question DROP COLUMN IF EXISTS answer_stats_correct;
+ALTER TABLE question DROP COLUMN IF EXISTS category;
+ALTER TABLE question DROP COLUMN IF EXISTS turn;
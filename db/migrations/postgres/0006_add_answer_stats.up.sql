@@ -0,0 +1,6 @@
+ALTER TABLE question ADD COLUMN IF NOT EXISTS turn INTEGER;
+ALTER TABLE question ADD COLUMN IF NOT EXISTS category VARCHAR(32);
+ALTER TABLE question ADD COLUMN IF NOT EXISTS answer_stats_correct INTEGER;
+ALTER TABLE question ADD COLUMN IF NOT EXISTS answer_stats_total INTEGER;
+-- answer_stats_correct / answer_stats_total, kept so it can be sorted on
+ALTER TABLE question ADD COLUMN IF NOT EXISTS correct_rate DOUBLE PRECISION;
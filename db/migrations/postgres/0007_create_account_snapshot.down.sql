@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS word_list_snapshot;
+DROP TABLE IF EXISTS account_snapshot;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS progress_snapshot (
+    id SERIAL PRIMARY KEY,
+    list_id INTEGER NOT NULL,
+    list_name VARCHAR(255) NOT NULL,
+    progress DOUBLE PRECISION NOT NULL,
+    played INTEGER NOT NULL DEFAULT 0,
+    correct INTEGER NOT NULL DEFAULT 0,
+    points INTEGER NOT NULL DEFAULT 0,
+    num_played INTEGER NOT NULL DEFAULT 0,
+    num_mastered INTEGER NOT NULL DEFAULT 0,
+    session_id VARCHAR(255) NOT NULL,
+    taken_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS progress_snapshot_list_id_idx ON progress_snapshot (list_id, taken_at);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS explanation (
+    question_id INTEGER PRIMARY KEY REFERENCES question (id) ON DELETE CASCADE,
+    explanation TEXT NOT NULL,
+    definition TEXT NOT NULL DEFAULT '',
+    mnemonic TEXT NOT NULL DEFAULT '',
+    model VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL
+);
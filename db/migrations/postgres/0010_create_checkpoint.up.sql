@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS checkpoint (
+    list_id INTEGER PRIMARY KEY,
+    session_id VARCHAR(255) NOT NULL DEFAULT '',
+    secret TEXT NOT NULL DEFAULT '',
+    progress DOUBLE PRECISION NOT NULL DEFAULT 0,
+    question_id INTEGER NOT NULL DEFAULT 0,
+    saved_at TIMESTAMPTZ NOT NULL
+);
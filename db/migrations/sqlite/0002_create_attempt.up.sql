@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS attempt (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    question_id INTEGER NOT NULL REFERENCES question (id) ON DELETE CASCADE,
+    choice_key VARCHAR(255) NOT NULL,
+    choice_value TEXT NOT NULL,
+    correct BOOLEAN NOT NULL DEFAULT FALSE,
+    points INTEGER NOT NULL DEFAULT 0,
+    bonus INTEGER NOT NULL DEFAULT 0,
+    response_time_ms INTEGER NOT NULL DEFAULT 0,
+    session_id VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS attempt_question_id_idx ON attempt (question_id);
+CREATE INDEX IF NOT EXISTS attempt_session_id_idx ON attempt (session_id);
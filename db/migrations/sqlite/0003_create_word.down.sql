@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS question_word_id_idx;
+ALTER TABLE question DROP COLUMN word_id;
+DROP TABLE IF EXISTS word;
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS word (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    lemma VARCHAR(255) NOT NULL UNIQUE,
+    first_seen TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_seen TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    times_asked INTEGER NOT NULL DEFAULT 0,
+    times_correct INTEGER NOT NULL DEFAULT 0,
+    -- (times_correct + 1) / (times_asked + 2), so unseen words start at 0.5
+    mastery REAL NOT NULL DEFAULT 0.5
+);
+
+ALTER TABLE question ADD COLUMN word_id INTEGER REFERENCES word (id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS question_word_id_idx ON question (word_id);
+
+INSERT OR IGNORE INTO word (lemma)
+SELECT DISTINCT LOWER(TRIM(target_word)) FROM question
+WHERE target_word IS NOT NULL AND TRIM(target_word) <> '';
+
+UPDATE question SET word_id = (
+    SELECT word.id FROM word WHERE word.lemma = LOWER(TRIM(question.target_word))
+);
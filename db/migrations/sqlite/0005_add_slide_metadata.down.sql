@@ -0,0 +1,7 @@
+ALTER TABLE question DROP COLUMN audio_id;
+ALTER TABLE question DROP COLUMN slide_template;
+ALTER TABLE question DROP COLUMN slide_type;
+ALTER TABLE question DROP COLUMN source_title;
+ALTER TABLE question DROP COLUMN source_url;
+ALTER TABLE question DROP COLUMN point_value;
+ALTER TABLE question DROP COLUMN slide_mode;
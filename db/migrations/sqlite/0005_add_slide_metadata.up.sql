@@ -0,0 +1,7 @@
+ALTER TABLE question ADD COLUMN slide_mode VARCHAR(32);
+ALTER TABLE question ADD COLUMN point_value INTEGER;
+ALTER TABLE question ADD COLUMN source_url TEXT;
+ALTER TABLE question ADD COLUMN source_title TEXT;
+ALTER TABLE question ADD COLUMN slide_type VARCHAR(32);
+ALTER TABLE question ADD COLUMN slide_template VARCHAR(64);
+ALTER TABLE question ADD COLUMN audio_id VARCHAR(64);
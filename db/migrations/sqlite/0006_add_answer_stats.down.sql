@@ -0,0 +1,5 @@
+ALTER TABLE question DROP COLUMN correct_rate;
+ALTER TABLE question DROP COLUMN answer_stats_total;
+ALTER TABLE question DROP COLUMN answer_stats_correct;
+ALTER TABLE question DROP COLUMN category;
+ALTER TABLE question DROP COLUMN turn;
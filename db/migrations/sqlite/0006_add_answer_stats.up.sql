@@ -0,0 +1,6 @@
+ALTER TABLE question ADD COLUMN turn INTEGER;
+ALTER TABLE question ADD COLUMN category VARCHAR(32);
+ALTER TABLE question ADD COLUMN answer_stats_correct INTEGER;
+ALTER TABLE question ADD COLUMN answer_stats_total INTEGER;
+-- answer_stats_correct / answer_stats_total, kept so it can be sorted on
+ALTER TABLE question ADD COLUMN correct_rate REAL;
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS account_snapshot (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    points INTEGER NOT NULL,
+    level_id VARCHAR(32) NOT NULL,
+    level_name VARCHAR(255) NOT NULL,
+    level_milestone INTEGER NOT NULL DEFAULT 0,
+    level_progress INTEGER NOT NULL DEFAULT 0,
+    num_played INTEGER NOT NULL,
+    num_mastered INTEGER NOT NULL,
+    taken_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- position keeps the order of pdata.lists; list ids are not unique there
+-- (logged out responses repeat -1).
+CREATE TABLE IF NOT EXISTS word_list_snapshot (
+    snapshot_id INTEGER NOT NULL REFERENCES account_snapshot (id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    list_id INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    word_count INTEGER NOT NULL,
+    progress REAL NOT NULL,
+    priority INTEGER NOT NULL,
+    is_current BOOLEAN NOT NULL DEFAULT FALSE,
+    PRIMARY KEY (snapshot_id, position)
+);
+
+CREATE INDEX IF NOT EXISTS word_list_snapshot_list_id_idx ON word_list_snapshot (list_id);
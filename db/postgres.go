@@ -0,0 +1,693 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/rodatboat/go-vocab/model"
+)
+
+type PostgresStore struct {
+	Conn *pgx.Conn
+}
+
+// Connects to postgres. Tables are created by MigrateUp.
+func OpenPostgres(ctx context.Context, dsn string) (*PostgresStore, error) {
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to database: %w", err)
+	}
+
+	return &PostgresStore{Conn: conn}, nil
+}
+
+func (s *PostgresStore) SaveQuestion(ctx context.Context, question model.Question) (int, error) {
+	query := `
+		INSERT INTO question (
+			question_type,
+			question,
+			question_context,
+			question_code,
+			question_html,
+			answer,
+			answer_data_key,
+			difficulty,
+			choices,
+			correct,
+			target_word,
+			slide_mode,
+			point_value,
+			source_url,
+			source_title,
+			slide_type,
+			slide_template,
+			audio_id,
+			turn,
+			category,
+			answer_stats_correct,
+			answer_stats_total,
+			correct_rate
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11,
+			$12, $13, $14, $15, $16, $17, $18,
+			$19, $20, $21, $22, $23
+		)
+		ON CONFLICT (question_type, question_context, question) DO UPDATE SET
+			answer = CASE WHEN question.correct THEN question.answer ELSE $6 END,
+			answer_data_key = CASE WHEN question.correct THEN question.answer_data_key ELSE $7 END,
+			correct = question.correct OR $10,
+			target_word = CASE WHEN question.correct THEN question.target_word ELSE $11 END,
+			choices = CASE WHEN ` + emptyChoices + ` THEN $9 ELSE question.choices END
+		RETURNING id
+	`
+
+	choicesJson, err := marshalChoices(question.Choices)
+	if err != nil {
+		return 0, err
+	}
+
+	// The question and its word link are saved together, or not at all.
+	tx, err := s.Conn.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var id int
+	err = tx.QueryRow(ctx, query,
+		question.QuestionType,
+		question.Question,
+		question.QuestionContext,
+		question.Code,
+		question.DecodedCode,
+		question.Answer,
+		question.AnswerKey,
+		question.Difficulty,
+		choicesJson,
+		question.IsCorrect,
+		question.TargetWord,
+		question.Mode,
+		question.PointValue,
+		question.SourceURL,
+		question.SourceTitle,
+		question.SlideType,
+		question.Template,
+		question.AudioID,
+		question.Turn,
+		question.Category,
+		question.AnswerStats.Correct,
+		question.AnswerStats.Total,
+		question.CorrectRate).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := s.linkWord(ctx, tx, id, question.TargetWord, answered(question)); err != nil {
+		return 0, err
+	}
+	return id, tx.Commit(ctx)
+}
+
+func (s *PostgresStore) linkWord(ctx context.Context, tx pgx.Tx, questionId int, targetWord string, replace bool) error {
+	lemma := normalizeLemma(targetWord)
+	if lemma == "" {
+		return nil
+	}
+	now := time.Now().UTC()
+
+	var previous *int
+	err := tx.QueryRow(ctx, `SELECT word_id FROM question WHERE id = $1`, questionId).Scan(&previous)
+	if err != nil {
+		return err
+	}
+	if previous != nil && !replace {
+		_, err = tx.Exec(ctx, `UPDATE word SET last_seen = $1 WHERE id = $2`, now, *previous)
+		return err
+	}
+
+	var wordId int
+	err = tx.QueryRow(ctx, `
+		INSERT INTO word (lemma, first_seen, last_seen) VALUES ($1, $2, $2)
+		ON CONFLICT (lemma) DO UPDATE SET last_seen = $2
+		RETURNING id`, lemma, now).Scan(&wordId)
+	if err != nil {
+		return fmt.Errorf("saving word %q: %w", lemma, err)
+	}
+	if previous != nil && *previous == wordId {
+		return nil
+	}
+	if _, err := tx.Exec(ctx, `UPDATE question SET word_id = $1 WHERE id = $2`, wordId, questionId); err != nil {
+		return err
+	}
+	if previous == nil {
+		return nil
+	}
+	// The guessed word goes when nothing else refers to it.
+	_, err = tx.Exec(ctx, `
+		DELETE FROM word WHERE id = $1 AND times_asked = 0
+			AND NOT EXISTS (SELECT 1 FROM question WHERE word_id = $1)
+			AND NOT EXISTS (SELECT 1 FROM review WHERE word_id = $1)`, *previous)
+	return err
+}
+
+func (s *PostgresStore) GetQuestion(ctx context.Context, id int) (*model.Question, error) {
+	row := s.Conn.QueryRow(ctx, `SELECT `+questionColumns+` FROM question WHERE id = $1`, id)
+	question, err := scanQuestion(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return question, err
+}
+
+func (s *PostgresStore) FindQuestion(ctx context.Context, questionType, questionContext, question string) (*model.Question, error) {
+	row := s.Conn.QueryRow(ctx, `SELECT `+questionColumns+` FROM question
+		WHERE question_type = $1 AND question_context = $2 AND question = $3`,
+		questionType, questionContext, question)
+	found, err := scanQuestion(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return found, err
+}
+
+func (s *PostgresStore) ListQuestions(ctx context.Context, filter QuestionFilter) ([]model.Question, error) {
+	query := `SELECT ` + questionColumns + ` FROM question
+		WHERE ($1 = '' OR question_type = $1) AND ($2 = FALSE OR correct = TRUE)
+			AND ($3 = 0 OR word_id = $3)
+		ORDER BY id`
+	args := []interface{}{filter.QuestionType, filter.CorrectOnly, filter.WordID}
+	if filter.Limit > 0 {
+		query += ` LIMIT $4`
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := s.Conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var questions []model.Question
+	for rows.Next() {
+		question, err := scanQuestion(rows)
+		if err != nil {
+			return nil, err
+		}
+		questions = append(questions, *question)
+	}
+	return questions, rows.Err()
+}
+
+func (s *PostgresStore) SaveAttempt(ctx context.Context, attempt model.Attempt) (int, error) {
+	if attempt.CreatedAt.IsZero() {
+		attempt.CreatedAt = time.Now().UTC()
+	}
+
+	tx, err := s.Conn.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var id int
+	err = tx.QueryRow(ctx, `
+		INSERT INTO attempt (
+			question_id,
+			choice_key,
+			choice_value,
+			correct,
+			points,
+			bonus,
+			response_time_ms,
+			session_id,
+			created_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9
+		)
+		RETURNING id`,
+		attempt.QuestionID,
+		attempt.ChoiceKey,
+		attempt.ChoiceValue,
+		attempt.IsCorrect,
+		attempt.Points,
+		attempt.Bonus,
+		attempt.ResponseTime,
+		attempt.SessionId,
+		attempt.CreatedAt).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	correct := 0
+	if attempt.IsCorrect {
+		correct = 1
+	}
+	_, err = tx.Exec(ctx, fmt.Sprintf(attemptWordUpdate, "$1", "$2::int", "$3"),
+		attempt.QuestionID, correct, attempt.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("updating word counts: %w", err)
+	}
+	return id, tx.Commit(ctx)
+}
+
+func (s *PostgresStore) GetWord(ctx context.Context, lemma string) (*model.Word, error) {
+	row := s.Conn.QueryRow(ctx, `SELECT `+wordColumns+` FROM word WHERE lemma = $1`, normalizeLemma(lemma))
+	word, err := scanWord(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return word, err
+}
+
+func (s *PostgresStore) ListWords(ctx context.Context) ([]model.Word, error) {
+	rows, err := s.Conn.Query(ctx, `SELECT `+wordColumns+` FROM word ORDER BY lemma`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var words []model.Word
+	for rows.Next() {
+		word, err := scanWord(rows)
+		if err != nil {
+			return nil, err
+		}
+		words = append(words, *word)
+	}
+	return words, rows.Err()
+}
+
+func (s *PostgresStore) ListAttempts(ctx context.Context, filter AttemptFilter) ([]model.Attempt, error) {
+	query := `SELECT ` + attemptColumns + ` FROM attempt
+		WHERE ($1 = 0 OR question_id = $1) AND ($2 = '' OR session_id = $2)
+		ORDER BY created_at, id`
+	args := []interface{}{filter.QuestionID, filter.SessionId}
+	if filter.Limit > 0 {
+		query += ` LIMIT $3`
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := s.Conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attempts []model.Attempt
+	for rows.Next() {
+		attempt, err := scanAttempt(rows)
+		if err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, *attempt)
+	}
+	return attempts, rows.Err()
+}
+
+func (s *PostgresStore) SaveReview(ctx context.Context, review model.Review) error {
+	_, err := s.Conn.Exec(ctx, `
+		INSERT INTO review (
+			word_id,
+			ease,
+			interval_days,
+			repetitions,
+			due_at,
+			last_reviewed_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6
+		)
+		ON CONFLICT (word_id) DO UPDATE SET
+			ease = $2,
+			interval_days = $3,
+			repetitions = $4,
+			due_at = $5,
+			last_reviewed_at = $6`,
+		review.WordID,
+		review.Ease,
+		review.Interval,
+		review.Repetitions,
+		review.DueAt.UTC(),
+		review.LastReviewedAt.UTC())
+	return err
+}
+
+func (s *PostgresStore) ListReviews(ctx context.Context) ([]model.Review, error) {
+	rows, err := s.Conn.Query(ctx, `SELECT `+reviewColumns+` FROM review ORDER BY due_at, word_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reviews []model.Review
+	for rows.Next() {
+		review, err := scanReview(rows)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, *review)
+	}
+	return reviews, rows.Err()
+}
+
+func (s *PostgresStore) SaveExplanation(ctx context.Context, explanation model.Explanation) error {
+	_, err := s.Conn.Exec(ctx, `
+		INSERT INTO explanation (
+			question_id,
+			explanation,
+			definition,
+			mnemonic,
+			model,
+			created_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6
+		)
+		ON CONFLICT (question_id) DO UPDATE SET
+			explanation = $2,
+			definition = $3,
+			mnemonic = $4,
+			model = $5,
+			created_at = $6`,
+		explanation.QuestionID,
+		explanation.Explanation,
+		explanation.Definition,
+		explanation.Mnemonic,
+		explanation.Model,
+		explanation.CreatedAt.UTC())
+	return err
+}
+
+func (s *PostgresStore) GetExplanation(ctx context.Context, questionId int) (*model.Explanation, error) {
+	row := s.Conn.QueryRow(ctx, `SELECT `+explanationColumns+` FROM explanation WHERE question_id = $1`, questionId)
+	explanation, err := scanExplanation(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return explanation, err
+}
+
+func (s *PostgresStore) ListExplanations(ctx context.Context) ([]model.Explanation, error) {
+	rows, err := s.Conn.Query(ctx, `SELECT `+explanationColumns+` FROM explanation ORDER BY question_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var explanations []model.Explanation
+	for rows.Next() {
+		explanation, err := scanExplanation(rows)
+		if err != nil {
+			return nil, err
+		}
+		explanations = append(explanations, *explanation)
+	}
+	return explanations, rows.Err()
+}
+
+func (s *PostgresStore) SaveCheckpoint(ctx context.Context, checkpoint model.Checkpoint) error {
+	if checkpoint.SavedAt.IsZero() {
+		checkpoint.SavedAt = time.Now().UTC()
+	}
+	_, err := s.Conn.Exec(ctx, `
+		INSERT INTO checkpoint (
+			list_id,
+			session_id,
+			secret,
+			progress,
+			question_id,
+			saved_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6
+		)
+		ON CONFLICT (list_id) DO UPDATE SET
+			session_id = $2,
+			secret = $3,
+			progress = $4,
+			question_id = $5,
+			saved_at = $6`,
+		checkpoint.ListId,
+		checkpoint.SessionId,
+		checkpoint.Secret,
+		checkpoint.Progress,
+		checkpoint.QuestionID,
+		checkpoint.SavedAt.UTC())
+	return err
+}
+
+func (s *PostgresStore) GetCheckpoint(ctx context.Context, listId int) (*model.Checkpoint, error) {
+	row := s.Conn.QueryRow(ctx, `SELECT `+checkpointColumns+` FROM checkpoint WHERE list_id = $1`, listId)
+	checkpoint, err := scanCheckpoint(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return checkpoint, err
+}
+
+func (s *PostgresStore) SaveAccountSnapshot(ctx context.Context, snapshot model.AccountSnapshot) (int, error) {
+	if snapshot.TakenAt.IsZero() {
+		snapshot.TakenAt = time.Now().UTC()
+	}
+
+	tx, err := s.Conn.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var id int
+	err = tx.QueryRow(ctx, `
+		INSERT INTO account_snapshot (
+			points,
+			level_id,
+			level_name,
+			level_milestone,
+			level_progress,
+			num_played,
+			num_mastered,
+			taken_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8
+		)
+		RETURNING id`,
+		snapshot.Points,
+		snapshot.Level.ID,
+		snapshot.Level.Name,
+		snapshot.Level.Milestone,
+		snapshot.Level.Progress,
+		snapshot.NumPlayed,
+		snapshot.NumMastered,
+		snapshot.TakenAt).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, list := range snapshot.Lists {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO word_list_snapshot (
+				snapshot_id,
+				position,
+				list_id,
+				name,
+				word_count,
+				progress,
+				priority,
+				is_current
+			) VALUES (
+				$1, $2, $3, $4, $5, $6, $7, $8
+			)`,
+			id,
+			i,
+			list.ID,
+			list.Name,
+			list.WordCount,
+			list.CompletionPercentage,
+			list.Priority,
+			list.Current)
+		if err != nil {
+			return 0, fmt.Errorf("saving list %d: %w", list.ID, err)
+		}
+	}
+	return id, tx.Commit(ctx)
+}
+
+func (s *PostgresStore) ListAccountSnapshots(ctx context.Context) ([]model.AccountSnapshot, error) {
+	rows, err := s.Conn.Query(ctx, `SELECT `+accountSnapshotColumns+` FROM account_snapshot ORDER BY taken_at, id`)
+	if err != nil {
+		return nil, err
+	}
+	var snapshots []model.AccountSnapshot
+	for rows.Next() {
+		snapshot, err := scanAccountSnapshot(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		snapshots = append(snapshots, *snapshot)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	listRows, err := s.Conn.Query(ctx, `SELECT `+wordListSnapshotColumns+` FROM word_list_snapshot ORDER BY snapshot_id, position`)
+	if err != nil {
+		return nil, err
+	}
+	defer listRows.Close()
+	if err := attachWordLists(snapshots, listRows.Next, listRows); err != nil {
+		return nil, err
+	}
+	return snapshots, listRows.Err()
+}
+
+func (s *PostgresStore) SaveProgressSnapshot(ctx context.Context, snapshot model.ProgressSnapshot) (int, error) {
+	if snapshot.TakenAt.IsZero() {
+		snapshot.TakenAt = time.Now().UTC()
+	}
+
+	var id int
+	err := s.Conn.QueryRow(ctx, `
+		INSERT INTO progress_snapshot (
+			list_id,
+			list_name,
+			progress,
+			played,
+			correct,
+			points,
+			num_played,
+			num_mastered,
+			session_id,
+			taken_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+		)
+		RETURNING id`,
+		snapshot.ListId,
+		snapshot.ListName,
+		snapshot.Progress,
+		snapshot.Played,
+		snapshot.Correct,
+		snapshot.Points,
+		snapshot.NumPlayed,
+		snapshot.NumMastered,
+		snapshot.SessionId,
+		snapshot.TakenAt).Scan(&id)
+	return id, err
+}
+
+func (s *PostgresStore) ListProgressSnapshots(ctx context.Context, filter ProgressFilter) ([]model.ProgressSnapshot, error) {
+	query := `SELECT ` + progressSnapshotColumns + ` FROM progress_snapshot
+		WHERE ($1 = 0 OR list_id = $1)
+		ORDER BY taken_at, id`
+	args := []interface{}{filter.ListId}
+	if filter.Limit > 0 {
+		query += ` LIMIT $2`
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := s.Conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snapshots []model.ProgressSnapshot
+	for rows.Next() {
+		snapshot, err := scanProgressSnapshot(rows)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, *snapshot)
+	}
+	return snapshots, rows.Err()
+}
+
+func (s *PostgresStore) Stats(ctx context.Context) (*model.QuestionStats, error) {
+	rows, err := s.Conn.Query(ctx, statsQuery)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := scanStats(rows.Next, rows.Scan, rows.Err)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.Conn.QueryRow(ctx, attemptStatsQuery).Scan(&stats.Attempts, &stats.CorrectAttempts)
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+func (s *PostgresStore) Close() error {
+	return s.Conn.Close(context.Background())
+}
+
+func (s *PostgresStore) MigrateUp(ctx context.Context) ([]Migration, error) {
+	return migrateUp(ctx, s, "postgres")
+}
+
+func (s *PostgresStore) MigrateDown(ctx context.Context, steps int) ([]Migration, error) {
+	return migrateDown(ctx, s, "postgres", steps)
+}
+
+func (s *PostgresStore) MigrationStatus(ctx context.Context) ([]MigrationStatus, error) {
+	return migrationStatus(ctx, s, "postgres")
+}
+
+func (s *PostgresStore) ensureMigrationsTable(ctx context.Context) error {
+	_, err := s.Conn.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL
+		)`)
+	return err
+}
+
+func (s *PostgresStore) appliedMigrations(ctx context.Context) (map[int]time.Time, error) {
+	rows, err := s.Conn.Query(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+func (s *PostgresStore) applyMigration(ctx context.Context, migration Migration, up bool) error {
+	tx, err := s.Conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if up {
+		if _, err := tx.Exec(ctx, migration.Up); err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)`,
+			migration.Version, migration.Name, time.Now().UTC())
+	} else {
+		if _, err := tx.Exec(ctx, migration.Down); err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `DELETE FROM schema_migrations WHERE version = $1`, migration.Version)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,652 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rodatboat/go-vocab/model"
+)
+
+// Every backend runs the same checks. Postgres only runs when
+// GOVOCAB_TEST_POSTGRES_DSN points at a disposable database.
+func openTestStores(t *testing.T) map[string]QuestionStore {
+	t.Helper()
+	ctx := context.Background()
+	stores := map[string]QuestionStore{}
+
+	memory, err := Open(ctx, "memory://")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stores["memory"] = memory
+
+	sqlite, err := Open(ctx, "sqlite://"+filepath.Join(t.TempDir(), "vocab.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stores["sqlite"] = sqlite
+
+	if dsn := os.Getenv("GOVOCAB_TEST_POSTGRES_DSN"); dsn != "" {
+		postgres, err := Open(ctx, dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := postgres.(*PostgresStore).Conn.Exec(ctx, "TRUNCATE question RESTART IDENTITY CASCADE"); err != nil {
+			t.Fatal(err)
+		}
+		stores["postgres"] = postgres
+	}
+
+	for _, store := range stores {
+		t.Cleanup(func() { store.Close() })
+	}
+	return stores
+}
+
+func testQuestion(questionType, question string) model.Question {
+	return model.Question{
+		QuestionType:    questionType,
+		Code:            "PGRpdj48L2Rpdj4=",
+		DecodedCode:     "<div></div>",
+		Difficulty:      2.17,
+		QuestionContext: "context for " + question,
+		Question:        question,
+		Choices: []model.QuestionChoices{
+			{Key: "a1", Value: "first"},
+			{Key: "b2", Value: "second"},
+		},
+		Mode:        "review",
+		PointValue:  10,
+		SourceURL:   "https://www.vocabulary.com/cvid/abc",
+		SourceTitle: "Gathering Blue",
+		SlideType:   "choice",
+		Template:    "multiple-choice",
+		Turn:        66961,
+		Category:    "definition",
+		AnswerStats: model.AnswerStats{Correct: 21081, Total: 32211},
+		CorrectRate: 21081.0 / 32211.0,
+	}
+}
+
+func TestQuestionStores(t *testing.T) {
+	for name, store := range openTestStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			id, err := store.SaveQuestion(ctx, testQuestion("S", "first question"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := store.GetQuestion(ctx, id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Question != "first question" || len(got.Choices) != 2 || got.Difficulty != 2.17 {
+				t.Errorf("unexpected question read back: %+v", got)
+			}
+			if got.Mode != "review" || got.PointValue != 10 || got.SourceTitle != "Gathering Blue" || got.Template != "multiple-choice" {
+				t.Errorf("slide metadata not read back: %+v", got)
+			}
+			if got.Turn != 66961 || got.Category != "definition" || got.AnswerStats.Total != 32211 || got.CorrectRate != 21081.0/32211.0 {
+				t.Errorf("answer stats not read back: %+v", got)
+			}
+
+			// A wrong answer is overwritten by the correct one...
+			answered := testQuestion("S", "first question")
+			answered.Answer, answered.AnswerKey = "second", "b2"
+			if _, err := store.SaveQuestion(ctx, answered); err != nil {
+				t.Fatal(err)
+			}
+			answered.Answer, answered.AnswerKey, answered.IsCorrect = "first", "a1", true
+			sameId, err := store.SaveQuestion(ctx, answered)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sameId != id {
+				t.Errorf("upsert returned id %d, want %d", sameId, id)
+			}
+
+			// ...but a correct answer is never overwritten.
+			answered.Answer, answered.AnswerKey, answered.IsCorrect = "second", "b2", false
+			if _, err := store.SaveQuestion(ctx, answered); err != nil {
+				t.Fatal(err)
+			}
+			got, err = store.FindQuestion(ctx, "S", "context for first question", "first question")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.IsCorrect || got.AnswerKey != "a1" {
+				t.Errorf("correct answer was overwritten: %+v", got)
+			}
+
+			if _, err := store.SaveQuestion(ctx, testQuestion("D", "second question")); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = store.FindQuestion(ctx, "S", "", "missing")
+			if !errors.Is(err, ErrNotFound) {
+				t.Errorf("want ErrNotFound, got %v", err)
+			}
+
+			questions, err := store.ListQuestions(ctx, QuestionFilter{QuestionType: "D"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(questions) != 1 || questions[0].Question != "second question" {
+				t.Errorf("unexpected D-type questions: %+v", questions)
+			}
+			questions, err = store.ListQuestions(ctx, QuestionFilter{CorrectOnly: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(questions) != 1 {
+				t.Errorf("want 1 correct question, got %d", len(questions))
+			}
+
+			for i, correct := range []bool{false, true} {
+				_, err := store.SaveAttempt(ctx, model.Attempt{
+					QuestionID:   id,
+					ChoiceKey:    "b2",
+					ChoiceValue:  "second",
+					IsCorrect:    correct,
+					Points:       10 * i,
+					ResponseTime: 4500,
+					SessionId:    "session-1",
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			attempts, err := store.ListAttempts(ctx, AttemptFilter{QuestionID: id})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(attempts) != 2 || attempts[0].IsCorrect || !attempts[1].IsCorrect {
+				t.Errorf("unexpected attempt history: %+v", attempts)
+			}
+			if attempts[1].Points != 10 || attempts[1].ResponseTime != 4500 || attempts[1].CreatedAt.IsZero() {
+				t.Errorf("attempt fields not read back: %+v", attempts[1])
+			}
+			attempts, err = store.ListAttempts(ctx, AttemptFilter{SessionId: "other"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(attempts) != 0 {
+				t.Errorf("want no attempts for other session, got %d", len(attempts))
+			}
+			if _, err := store.SaveAttempt(ctx, model.Attempt{QuestionID: 9999, SessionId: "session-1"}); err == nil {
+				t.Error("want error saving attempt for unknown question")
+			}
+
+			stats, err := store.Stats(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stats.Total != 2 || stats.Correct != 1 || stats.Answered != 1 || stats.ByType["S"].Correct != 1 {
+				t.Errorf("unexpected stats: %+v", stats)
+			}
+			if stats.Attempts != 2 || stats.CorrectAttempts != 1 {
+				t.Errorf("unexpected attempt stats: %+v", stats)
+			}
+		})
+	}
+}
+
+func TestChoicesFilledIn(t *testing.T) {
+	for name, store := range openTestStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			question := testQuestion("S", "choices missing at first")
+			choices := question.Choices
+			question.Choices = nil
+			question.Answer, question.AnswerKey, question.IsCorrect = "second", "b2", true
+			id, err := store.SaveQuestion(ctx, question)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			question.Choices = choices
+			question.Answer, question.AnswerKey, question.IsCorrect = "first", "a1", false
+			if _, err := store.SaveQuestion(ctx, question); err != nil {
+				t.Fatal(err)
+			}
+			saved, err := store.GetQuestion(ctx, id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(saved.Choices, choices) {
+				t.Errorf("choices = %+v, want %+v", saved.Choices, choices)
+			}
+			if !saved.IsCorrect || saved.AnswerKey != "b2" {
+				t.Errorf("correct answer overwritten: %+v", saved)
+			}
+
+			question.Choices = choices[:1]
+			if _, err := store.SaveQuestion(ctx, question); err != nil {
+				t.Fatal(err)
+			}
+			if saved, err := store.GetQuestion(ctx, id); err != nil || len(saved.Choices) != 2 {
+				t.Errorf("stored choices replaced: %+v (%v)", saved, err)
+			}
+		})
+	}
+}
+
+func TestOpenUnknownScheme(t *testing.T) {
+	if _, err := Open(context.Background(), "mysql://localhost"); err == nil {
+		t.Error("want error for unsupported scheme")
+	}
+	if _, err := Open(context.Background(), "vocab.db"); err == nil {
+		t.Error("want error for DSN without scheme")
+	}
+}
+
+func TestWordLinking(t *testing.T) {
+	for name, store := range openTestStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			first := testQuestion("S", "endowment has the same meaning as:")
+			first.TargetWord = "Endowment "
+			firstId, err := store.SaveQuestion(ctx, first)
+			if err != nil {
+				t.Fatal(err)
+			}
+			second := testQuestion("D", "endowment means:")
+			second.TargetWord = "endowment"
+			secondId, err := store.SaveQuestion(ctx, second)
+			if err != nil {
+				t.Fatal(err)
+			}
+			unlinkedId, err := store.SaveQuestion(ctx, testQuestion("F", "blanked excerpt"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			word, err := store.GetWord(ctx, "endowment")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, id := range []int{firstId, secondId} {
+				question, err := store.GetQuestion(ctx, id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if question.WordID != word.ID {
+					t.Errorf("question %d linked to word %d, want %d", id, question.WordID, word.ID)
+				}
+			}
+			unlinked, err := store.GetQuestion(ctx, unlinkedId)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if unlinked.WordID != 0 {
+				t.Errorf("question without target word linked to word %d", unlinked.WordID)
+			}
+
+			for _, attempt := range []model.Attempt{
+				{QuestionID: firstId, IsCorrect: true, SessionId: "s"},
+				{QuestionID: secondId, IsCorrect: false, SessionId: "s"},
+				{QuestionID: unlinkedId, IsCorrect: true, SessionId: "s"},
+			} {
+				if _, err := store.SaveAttempt(ctx, attempt); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			word, err = store.GetWord(ctx, "ENDOWMENT")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if word.TimesAsked != 2 || word.TimesCorrect != 1 || word.Mastery != masteryEstimate(2, 1) {
+				t.Errorf("unexpected word counters: %+v", word)
+			}
+
+			words, err := store.ListWords(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(words) != 1 || words[0].Lemma != "endowment" {
+				t.Errorf("unexpected words: %+v", words)
+			}
+		})
+	}
+}
+
+func TestWordRelinkedOnAnswer(t *testing.T) {
+	for name, store := range openTestStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			// Before answering, the target word is a <strong> word of the
+			// instructions.
+			question := testQuestion("H", "the spores were ___ by the wind")
+			question.TargetWord = "spores"
+			id, err := store.SaveQuestion(ctx, question)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			question.Answer, question.AnswerKey, question.TargetWord = "first", "a1", "diffused"
+			if _, err := store.SaveQuestion(ctx, question); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.SaveAttempt(ctx, model.Attempt{QuestionID: id, IsCorrect: true, SessionId: "s"}); err != nil {
+				t.Fatal(err)
+			}
+
+			words, err := store.ListWords(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(words) != 1 || words[0].Lemma != "diffused" || words[0].TimesAsked != 1 {
+				t.Fatalf("words = %+v", words)
+			}
+			saved, err := store.GetQuestion(ctx, id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if saved.WordID != words[0].ID {
+				t.Errorf("question linked to word %d, want %d", saved.WordID, words[0].ID)
+			}
+
+			// Seen again unanswered, it keeps the answered word.
+			question.Answer, question.AnswerKey, question.TargetWord = "", "", "spores"
+			if _, err := store.SaveQuestion(ctx, question); err != nil {
+				t.Fatal(err)
+			}
+			saved, err = store.GetQuestion(ctx, id)
+			if err != nil || saved.WordID != words[0].ID {
+				t.Errorf("question relinked by an unanswered save: %+v (%v)", saved, err)
+			}
+			if words, err := store.ListWords(ctx); err != nil || len(words) != 1 {
+				t.Errorf("words after an unanswered save = %+v (%v)", words, err)
+			}
+		})
+	}
+}
+
+// A failed word write takes the question with it, so no question is left
+// without its word.
+func TestSaveQuestionAtomic(t *testing.T) {
+	ctx := context.Background()
+	store, err := Open(ctx, "sqlite://"+filepath.Join(t.TempDir(), "vocab.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	_, err = store.(*SQLiteStore).DB.ExecContext(ctx, `
+		CREATE TRIGGER no_words BEFORE INSERT ON word
+		BEGIN SELECT RAISE(ABORT, 'no words'); END`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	question := testQuestion("S", "abate means:")
+	question.TargetWord = "abate"
+	if _, err := store.SaveQuestion(ctx, question); err == nil {
+		t.Fatal("want the word insert to fail")
+	}
+	if _, err := store.FindQuestion(ctx, "S", question.QuestionContext, question.Question); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want the question rolled back, got %v", err)
+	}
+}
+
+func TestReviews(t *testing.T) {
+	for name, store := range openTestStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			question := testQuestion("S", "abate means:")
+			question.TargetWord = "abate"
+			if _, err := store.SaveQuestion(ctx, question); err != nil {
+				t.Fatal(err)
+			}
+			word, err := store.GetWord(ctx, "abate")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			questions, err := store.ListQuestions(ctx, QuestionFilter{WordID: word.ID})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(questions) != 1 || questions[0].Question != "abate means:" {
+				t.Errorf("unexpected questions for word: %+v", questions)
+			}
+
+			reviewedAt := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+			review := model.Review{
+				WordID:         word.ID,
+				Ease:           2.36,
+				Interval:       6,
+				Repetitions:    2,
+				DueAt:          reviewedAt.AddDate(0, 0, 6),
+				LastReviewedAt: reviewedAt,
+			}
+			if err := store.SaveReview(ctx, review); err != nil {
+				t.Fatal(err)
+			}
+			review.Interval, review.Repetitions = 15, 3
+			review.DueAt = reviewedAt.AddDate(0, 0, 15)
+			if err := store.SaveReview(ctx, review); err != nil {
+				t.Fatal(err)
+			}
+
+			reviews, err := store.ListReviews(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(reviews) != 1 {
+				t.Fatalf("want 1 review, got %+v", reviews)
+			}
+			got := reviews[0]
+			if got.Interval != 15 || got.Repetitions != 3 || got.Ease != 2.36 ||
+				!got.DueAt.Equal(review.DueAt) || !got.LastReviewedAt.Equal(reviewedAt) {
+				t.Errorf("review read back as %+v, want %+v", got, review)
+			}
+
+			if err := store.SaveReview(ctx, model.Review{WordID: 9999}); err == nil {
+				t.Error("want error saving review for unknown word")
+			}
+		})
+	}
+}
+
+func TestExplanations(t *testing.T) {
+	for name, store := range openTestStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			id, err := store.SaveQuestion(ctx, testQuestion("S", "abate means:"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.GetExplanation(ctx, id); !errors.Is(err, ErrNotFound) {
+				t.Errorf("want ErrNotFound before explaining, got %v", err)
+			}
+
+			createdAt := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+			explanation := model.Explanation{
+				QuestionID:  id,
+				Explanation: "To abate is to lessen; \"raise\" is the opposite.",
+				Definition:  "become less intense",
+				Mnemonic:    "a bate = a bit less",
+				Model:       "llama3.1",
+				CreatedAt:   createdAt,
+			}
+			if err := store.SaveExplanation(ctx, explanation); err != nil {
+				t.Fatal(err)
+			}
+			explanation.Mnemonic = "abate the debate"
+			if err := store.SaveExplanation(ctx, explanation); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := store.GetExplanation(ctx, id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Explanation != explanation.Explanation || got.Mnemonic != "abate the debate" ||
+				got.Model != "llama3.1" || !got.CreatedAt.Equal(createdAt) {
+				t.Errorf("explanation read back as %+v, want %+v", got, explanation)
+			}
+
+			explanations, err := store.ListExplanations(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(explanations) != 1 || explanations[0].QuestionID != id {
+				t.Errorf("unexpected explanations: %+v", explanations)
+			}
+
+			if err := store.SaveExplanation(ctx, model.Explanation{QuestionID: 9999}); err == nil {
+				t.Error("want error explaining an unknown question")
+			}
+		})
+	}
+}
+
+func TestCheckpoints(t *testing.T) {
+	for name, store := range openTestStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			if _, err := store.GetCheckpoint(ctx, 7); !errors.Is(err, ErrNotFound) {
+				t.Errorf("want ErrNotFound before saving, got %v", err)
+			}
+
+			savedAt := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+			checkpoint := model.Checkpoint{
+				ListId:     7,
+				SessionId:  "a1b2",
+				Secret:     "secret-1",
+				Progress:   0.25,
+				QuestionID: 3,
+				SavedAt:    savedAt,
+			}
+			if err := store.SaveCheckpoint(ctx, checkpoint); err != nil {
+				t.Fatal(err)
+			}
+			checkpoint.Secret = "secret-2"
+			checkpoint.Progress = 0.5
+			if err := store.SaveCheckpoint(ctx, checkpoint); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.SaveCheckpoint(ctx, model.Checkpoint{ListId: 8, Secret: "other"}); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := store.GetCheckpoint(ctx, 7)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Secret != "secret-2" || got.Progress != 0.5 || got.QuestionID != 3 ||
+				got.SessionId != "a1b2" || !got.SavedAt.Equal(savedAt) {
+				t.Errorf("checkpoint read back as %+v, want %+v", got, checkpoint)
+			}
+			if got, err := store.GetCheckpoint(ctx, 8); err != nil || got.SavedAt.IsZero() {
+				t.Errorf("want SavedAt set on save, got %+v (%v)", got, err)
+			}
+		})
+	}
+}
+
+func TestAccountSnapshots(t *testing.T) {
+	for name, store := range openTestStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			first := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+			for i, takenAt := range []time.Time{first.Add(time.Hour), first} {
+				_, err := store.SaveAccountSnapshot(ctx, model.AccountSnapshot{
+					Points:      12795855 + i,
+					Level:       model.Level{ID: "L17", Name: "Walking Dictionary", Progress: 74},
+					NumPlayed:   66961,
+					NumMastered: 4854,
+					Lists: []model.WordList{
+						{ID: 1993157, Name: "Giving Words", WordCount: 25, CompletionPercentage: 1, Completed: true},
+						{ID: 1623099, Name: "Cat Vocabulary", WordCount: 13, CompletionPercentage: 0.4, Priority: 1, Current: true},
+					},
+					TakenAt: takenAt,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			snapshots, err := store.ListAccountSnapshots(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(snapshots) != 2 || !snapshots[0].TakenAt.Equal(first) || snapshots[0].Points != 12795856 {
+				t.Fatalf("unexpected snapshots: %+v", snapshots)
+			}
+			got := snapshots[1]
+			if got.Level.Name != "Walking Dictionary" || got.NumMastered != 4854 || len(got.Lists) != 2 {
+				t.Errorf("unexpected snapshot: %+v", got)
+			}
+			if list := got.Lists[1]; list.Name != "Cat Vocabulary" || list.CompletionPercentage != 0.4 || !list.Current || list.Completed {
+				t.Errorf("unexpected list: %+v", list)
+			}
+			if !got.Lists[0].Completed {
+				t.Errorf("finished list not completed: %+v", got.Lists[0])
+			}
+		})
+	}
+}
+
+func TestProgressSnapshots(t *testing.T) {
+	for name, store := range openTestStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+			for i, listId := range []int{2444808, 1993157, 2444808} {
+				_, err := store.SaveProgressSnapshot(ctx, model.ProgressSnapshot{
+					ListId:      listId,
+					ListName:    "master",
+					Progress:    0.25 * float64(i+1),
+					Played:      10 * (i + 1),
+					Correct:     7 * (i + 1),
+					Points:      100,
+					NumMastered: 4850 + i,
+					SessionId:   "session-1",
+					TakenAt:     start.Add(time.Duration(i) * time.Minute),
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			snapshots, err := store.ListProgressSnapshots(ctx, ProgressFilter{ListId: 2444808})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(snapshots) != 2 {
+				t.Fatalf("want 2 snapshots for list, got %+v", snapshots)
+			}
+			last := snapshots[1]
+			if last.Progress != 0.75 || last.Played != 30 || last.NumMastered != 4852 || !last.TakenAt.Equal(start.Add(2*time.Minute)) {
+				t.Errorf("unexpected snapshot: %+v", last)
+			}
+			if last.Accuracy() != 0.7 {
+				t.Errorf("accuracy = %v, want 0.7", last.Accuracy())
+			}
+
+			snapshots, err = store.ListProgressSnapshots(ctx, ProgressFilter{Limit: 2})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(snapshots) != 2 || snapshots[1].ListId != 1993157 {
+				t.Errorf("unexpected limited snapshots: %+v", snapshots)
+			}
+		})
+	}
+}
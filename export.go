@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/rodatboat/go-vocab/db"
+	"github.com/rodatboat/go-vocab/export"
+	"github.com/rodatboat/go-vocab/model"
+)
+
+const exportUsage = `usage: go-vocab export anki [flags]
+
+  anki     write answered questions as an Anki deck (.apkg)
+`
+
+func runExport(globals, args []string) int {
+	// export has a single format, so its help is the help of anki.
+	if len(args) > 0 && isHelpFlag(args[0]) {
+		args = append([]string{"anki"}, args...)
+	}
+	if len(args) == 0 || args[0] != "anki" {
+		fmt.Fprint(os.Stderr, exportUsage)
+		return exitUsage
+	}
+
+	flags := newCommandFlags("export anki", exportUsage, globals)
+	output := flags.String("o", "go-vocab.apkg", "output file")
+	deckName := flags.String("deck", "go-vocab", "Anki deck name")
+	if err := flags.Parse(args[1:]); err != nil {
+		return parseExitCode(err)
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return exitUsage
+	}
+	conf, err := flags.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	ctx := context.Background()
+	store, err := db.Open(ctx, conf.StoreDSN())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error opening store:", err)
+		return exitFailure
+	}
+	defer store.Close()
+
+	questions, err := store.ListQuestions(ctx, db.QuestionFilter{})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error listing questions:", err)
+		return exitFailure
+	}
+
+	explanations, err := store.ListExplanations(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error listing explanations:", err)
+		return exitFailure
+	}
+	explanationByQuestion := map[int]model.Explanation{}
+	for _, explanation := range explanations {
+		explanationByQuestion[explanation.QuestionID] = explanation
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error creating deck:", err)
+		return exitFailure
+	}
+	notes, err := export.WriteAnki(ctx, file, *deckName, questions, explanationByQuestion)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(*output)
+		fmt.Fprintln(os.Stderr, "Error exporting deck:", err)
+		return exitFailure
+	}
+	fmt.Printf("Exported %d notes to %s\n", notes, *output)
+	return exitOK
+}
@@ -0,0 +1,525 @@
+package export
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rodatboat/go-vocab/model"
+	_ "modernc.org/sqlite"
+)
+
+// Fixed note type ids, so re-importing a deck updates the same note types
+// instead of adding copies.
+const (
+	multipleChoiceModelId = 1706515200001
+	spellingModelId       = 1706515200002
+)
+
+// Shown under the answer when the note has one.
+const explanationTemplate = `{{#Explanation}}<div class="explanation">{{Explanation}}</div>{{/Explanation}}`
+
+// Anki separates note fields with the unit separator.
+const fieldSeparator = "\x1f"
+
+var ErrNothingToExport = errors.New("no answered questions to export")
+
+const ankiSchema = `
+CREATE TABLE col (
+	id integer PRIMARY KEY,
+	crt integer NOT NULL,
+	mod integer NOT NULL,
+	scm integer NOT NULL,
+	ver integer NOT NULL,
+	dty integer NOT NULL,
+	usn integer NOT NULL,
+	ls integer NOT NULL,
+	conf text NOT NULL,
+	models text NOT NULL,
+	decks text NOT NULL,
+	dconf text NOT NULL,
+	tags text NOT NULL
+);
+CREATE TABLE notes (
+	id integer PRIMARY KEY,
+	guid text NOT NULL,
+	mid integer NOT NULL,
+	mod integer NOT NULL,
+	usn integer NOT NULL,
+	tags text NOT NULL,
+	flds text NOT NULL,
+	sfld integer NOT NULL,
+	csum integer NOT NULL,
+	flags integer NOT NULL,
+	data text NOT NULL
+);
+CREATE TABLE cards (
+	id integer PRIMARY KEY,
+	nid integer NOT NULL,
+	did integer NOT NULL,
+	ord integer NOT NULL,
+	mod integer NOT NULL,
+	usn integer NOT NULL,
+	type integer NOT NULL,
+	queue integer NOT NULL,
+	due integer NOT NULL,
+	ivl integer NOT NULL,
+	factor integer NOT NULL,
+	reps integer NOT NULL,
+	lapses integer NOT NULL,
+	left integer NOT NULL,
+	odue integer NOT NULL,
+	odid integer NOT NULL,
+	flags integer NOT NULL,
+	data text NOT NULL
+);
+CREATE TABLE revlog (
+	id integer PRIMARY KEY,
+	cid integer NOT NULL,
+	usn integer NOT NULL,
+	ease integer NOT NULL,
+	ivl integer NOT NULL,
+	lastIvl integer NOT NULL,
+	factor integer NOT NULL,
+	time integer NOT NULL,
+	type integer NOT NULL
+);
+CREATE TABLE graves (
+	usn integer NOT NULL,
+	oid integer NOT NULL,
+	type integer NOT NULL
+);
+CREATE INDEX ix_notes_usn ON notes (usn);
+CREATE INDEX ix_cards_usn ON cards (usn);
+CREATE INDEX ix_revlog_usn ON revlog (usn);
+CREATE INDEX ix_cards_nid ON cards (nid);
+CREATE INDEX ix_cards_sched ON cards (did, queue, due);
+CREATE INDEX ix_revlog_cid ON revlog (cid);
+CREATE INDEX ix_notes_csum ON notes (csum);
+`
+
+const cardCss = `.card {
+	font-family: arial;
+	font-size: 20px;
+	text-align: center;
+	color: black;
+	background-color: white;
+}
+.context { font-style: italic; margin-bottom: 1em; }
+.answer { font-weight: bold; }
+.explanation { font-size: 16px; text-align: left; margin-top: 1em; }
+`
+
+type ankiField struct {
+	Name   string        `json:"name"`
+	Ord    int           `json:"ord"`
+	Sticky bool          `json:"sticky"`
+	Rtl    bool          `json:"rtl"`
+	Font   string        `json:"font"`
+	Size   int           `json:"size"`
+	Media  []interface{} `json:"media"`
+}
+
+type ankiTemplate struct {
+	Name  string      `json:"name"`
+	Ord   int         `json:"ord"`
+	Qfmt  string      `json:"qfmt"`
+	Afmt  string      `json:"afmt"`
+	Did   interface{} `json:"did"`
+	Bqfmt string      `json:"bqfmt"`
+	Bafmt string      `json:"bafmt"`
+}
+
+type ankiModel struct {
+	Id        int64           `json:"id"`
+	Name      string          `json:"name"`
+	Type      int             `json:"type"`
+	Mod       int64           `json:"mod"`
+	Usn       int             `json:"usn"`
+	Sortf     int             `json:"sortf"`
+	Did       int64           `json:"did"`
+	Tmpls     []ankiTemplate  `json:"tmpls"`
+	Flds      []ankiField     `json:"flds"`
+	Css       string          `json:"css"`
+	LatexPre  string          `json:"latexPre"`
+	LatexPost string          `json:"latexPost"`
+	Tags      []string        `json:"tags"`
+	Vers      []interface{}   `json:"vers"`
+	Req       [][]interface{} `json:"req"`
+}
+
+func newAnkiModel(id int64, name string, deckId int64, now int64, sortField int, fields []string, qfmt, afmt string) ankiModel {
+	noteType := ankiModel{
+		Id:        id,
+		Name:      name,
+		Mod:       now,
+		Usn:       -1,
+		Sortf:     sortField,
+		Did:       deckId,
+		Css:       cardCss,
+		LatexPre:  "\\documentclass[12pt]{article}\n\\special{papersize=3in,5in}\n\\usepackage{amssymb,amsmath}\n\\pagestyle{empty}\n\\begin{document}\n",
+		LatexPost: "\\end{document}",
+		Tags:      []string{},
+		Vers:      []interface{}{},
+		// The card needs the sort field, the rest can be empty.
+		Req: [][]interface{}{{0, "all", []int{sortField}}},
+		Tmpls: []ankiTemplate{{
+			Name: "Card 1",
+			Qfmt: qfmt,
+			Afmt: afmt,
+		}},
+	}
+	for i, field := range fields {
+		noteType.Flds = append(noteType.Flds, ankiField{
+			Name:  field,
+			Ord:   i,
+			Font:  "Arial",
+			Size:  20,
+			Media: []interface{}{},
+		})
+	}
+	return noteType
+}
+
+// A note is one exported question, with its fields already escaped as HTML.
+type ankiNote struct {
+	guid      string
+	modelId   int64
+	fields    []string
+	sortField int
+	tags      []string
+}
+
+var tagUnsafe = regexp.MustCompile(`\s+`)
+
+func noteTags(question model.Question) []string {
+	tags := []string{"type::" + question.QuestionType}
+	if word := strings.ToLower(strings.TrimSpace(question.TargetWord)); word != "" {
+		tags = append(tags, "word::"+tagUnsafe.ReplaceAllString(word, "_"))
+	}
+	return tags
+}
+
+// The guid is derived from what identifies a question in the store, so the
+// same question exported twice is recognised by Anki as the same note.
+func noteGuid(question model.Question) string {
+	sum := sha1.Sum([]byte(question.QuestionType + fieldSeparator + question.QuestionContext + fieldSeparator + question.Question))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// explanationField is the definition, explanation and mnemonic as HTML, or
+// empty when the question was never explained.
+func explanationField(explanation *model.Explanation) string {
+	if explanation == nil {
+		return ""
+	}
+	var parts []string
+	if explanation.Definition != "" {
+		parts = append(parts, "<b>"+html.EscapeString(explanation.Definition)+"</b>")
+	}
+	if explanation.Explanation != "" {
+		parts = append(parts, html.EscapeString(explanation.Explanation))
+	}
+	if explanation.Mnemonic != "" {
+		parts = append(parts, "<i>"+html.EscapeString(explanation.Mnemonic)+"</i>")
+	}
+	return strings.Join(parts, "<br>")
+}
+
+// The value of the choice the site accepted, or the stored answer text when
+// no choice has the answer key.
+func answerText(question model.Question) string {
+	for _, choice := range question.Choices {
+		if question.AnswerKey != "" && choice.Key == question.AnswerKey {
+			return choice.Value
+		}
+	}
+	return question.Answer
+}
+
+// Only questions with a trustworthy answer make a card. Spelling answers come
+// from the slide itself, the rest need a correct attempt. Image questions
+// have no text answer and are left out.
+func toNote(question model.Question, explanation *model.Explanation) (ankiNote, bool) {
+	if question.QuestionType == "I" || question.Answer == "" {
+		return ankiNote{}, false
+	}
+
+	if question.QuestionType == "T" {
+		return ankiNote{
+			guid:    noteGuid(question),
+			modelId: spellingModelId,
+			fields: []string{
+				html.EscapeString(question.QuestionContext),
+				html.EscapeString(question.Answer),
+				explanationField(explanation),
+			},
+			sortField: 0,
+			tags:      noteTags(question),
+		}, true
+	}
+
+	if !question.IsCorrect {
+		return ankiNote{}, false
+	}
+	return ankiNote{
+		guid:    noteGuid(question),
+		modelId: multipleChoiceModelId,
+		fields: []string{
+			html.EscapeString(question.QuestionContext),
+			html.EscapeString(question.Question),
+			html.EscapeString(answerText(question)),
+			explanationField(explanation),
+		},
+		sortField: 1,
+		tags:      noteTags(question),
+	}, true
+}
+
+// Anki checks for duplicates with the first 8 hex digits of the sha1 of the
+// sort field.
+func fieldChecksum(field string) int64 {
+	sum := sha1.Sum([]byte(html.UnescapeString(field)))
+	checksum, _ := strconv.ParseInt(hex.EncodeToString(sum[:])[:8], 16, 64)
+	return checksum
+}
+
+func deckId(name string) int64 {
+	return 1<<32 + int64(crc32.ChecksumIEEE([]byte(name)))
+}
+
+// WriteAnki writes the answered questions as an .apkg deck and returns how
+// many notes it contains. Explanations are keyed by question ID and shown on
+// the back of the card.
+func WriteAnki(ctx context.Context, w io.Writer, deckName string, questions []model.Question, explanations map[int]model.Explanation) (int, error) {
+	var notes []ankiNote
+	for _, question := range questions {
+		var explanation *model.Explanation
+		if e, ok := explanations[question.ID]; ok {
+			explanation = &e
+		}
+		if note, ok := toNote(question, explanation); ok {
+			notes = append(notes, note)
+		}
+	}
+	if len(notes) == 0 {
+		return 0, ErrNothingToExport
+	}
+
+	dir, err := os.MkdirTemp("", "go-vocab-anki")
+	if err != nil {
+		return 0, err
+	}
+	defer os.RemoveAll(dir)
+
+	collectionPath := filepath.Join(dir, "collection.anki2")
+	if err := writeCollection(ctx, collectionPath, deckName, notes, time.Now()); err != nil {
+		return 0, fmt.Errorf("writing anki collection: %w", err)
+	}
+
+	archive := zip.NewWriter(w)
+	collection, err := archive.Create("collection.anki2")
+	if err != nil {
+		return 0, err
+	}
+	collectionFile, err := os.Open(collectionPath)
+	if err != nil {
+		return 0, err
+	}
+	defer collectionFile.Close()
+	if _, err := io.Copy(collection, collectionFile); err != nil {
+		return 0, err
+	}
+
+	// The media manifest maps archive entry names to file names. It stays
+	// empty since image questions, the only ones with pictures, are left out.
+	media, err := archive.Create("media")
+	if err != nil {
+		return 0, err
+	}
+	if _, err := io.WriteString(media, "{}"); err != nil {
+		return 0, err
+	}
+	return len(notes), archive.Close()
+}
+
+func writeCollection(ctx context.Context, path, deckName string, notes []ankiNote, now time.Time) error {
+	conn, err := sql.Open("sqlite", path)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, ankiSchema); err != nil {
+		return err
+	}
+	if err := insertCol(ctx, tx, deckName, now); err != nil {
+		return err
+	}
+
+	did := deckId(deckName)
+	// Note and card ids are millisecond timestamps in Anki, they only need to be unique.
+	baseId := now.UnixMilli()
+	for i, note := range notes {
+		noteId := baseId + int64(i)
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO notes (id, guid, mid, mod, usn, tags, flds, sfld, csum, flags, data)
+			VALUES (?1, ?2, ?3, ?4, -1, ?5, ?6, ?7, ?8, 0, '')`,
+			noteId,
+			note.guid,
+			note.modelId,
+			now.Unix(),
+			" "+strings.Join(note.tags, " ")+" ",
+			strings.Join(note.fields, fieldSeparator),
+			html.UnescapeString(note.fields[note.sortField]),
+			fieldChecksum(note.fields[note.sortField]))
+		if err != nil {
+			return err
+		}
+
+		// New cards, due in export order.
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO cards (id, nid, did, ord, mod, usn, type, queue, due, ivl, factor, reps, lapses, left, odue, odid, flags, data)
+			VALUES (?1, ?2, ?3, 0, ?4, -1, 0, 0, ?5, 0, 0, 0, 0, 0, 0, 0, 0, '')`,
+			noteId,
+			noteId,
+			did,
+			now.Unix(),
+			i+1)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func insertCol(ctx context.Context, tx *sql.Tx, deckName string, now time.Time) error {
+	did := deckId(deckName)
+	models := map[string]ankiModel{
+		strconv.FormatInt(multipleChoiceModelId, 10): newAnkiModel(
+			multipleChoiceModelId, "go-vocab multiple choice", did, now.Unix(), 1,
+			[]string{"Context", "Question", "Answer", "Explanation"},
+			`{{#Context}}<div class="context">{{Context}}</div>{{/Context}}<div class="question">{{Question}}</div>`,
+			`{{FrontSide}}<hr id="answer"><div class="answer">{{Answer}}</div>`+explanationTemplate),
+		strconv.FormatInt(spellingModelId, 10): newAnkiModel(
+			spellingModelId, "go-vocab spelling", did, now.Unix(), 0,
+			[]string{"Sentence", "Word", "Explanation"},
+			`<div class="context">{{Sentence}}</div><div class="question">Spell the missing word.</div>`,
+			`{{FrontSide}}<hr id="answer"><div class="answer">{{Word}}</div>`+explanationTemplate),
+	}
+	decks := map[string]interface{}{
+		"1":                        ankiDeck(1, "Default", now),
+		strconv.FormatInt(did, 10): ankiDeck(did, deckName, now),
+	}
+	dconf := map[string]interface{}{
+		"1": map[string]interface{}{
+			"id":       1,
+			"name":     "Default",
+			"mod":      0,
+			"usn":      0,
+			"maxTaken": 60,
+			"autoplay": true,
+			"timer":    0,
+			"replayq":  true,
+			"dyn":      false,
+			"new": map[string]interface{}{
+				"bury":          true,
+				"delays":        []int{1, 10},
+				"initialFactor": 2500,
+				"ints":          []int{1, 4, 7},
+				"order":         1,
+				"perDay":        20,
+				"separate":      true,
+			},
+			"lapse": map[string]interface{}{
+				"delays":      []int{10},
+				"leechAction": 0,
+				"leechFails":  8,
+				"minInt":      1,
+				"mult":        0,
+			},
+			"rev": map[string]interface{}{
+				"bury":     true,
+				"ease4":    1.3,
+				"fuzz":     0.05,
+				"ivlFct":   1,
+				"maxIvl":   36500,
+				"minSpace": 1,
+				"perDay":   100,
+			},
+		},
+	}
+	conf := map[string]interface{}{
+		"activeDecks":   []int64{1},
+		"curDeck":       1,
+		"newSpread":     0,
+		"collapseTime":  1200,
+		"timeLim":       0,
+		"estTimes":      true,
+		"dueCounts":     true,
+		"curModel":      nil,
+		"nextPos":       1,
+		"sortType":      "noteFld",
+		"sortBackwards": false,
+		"addToCur":      true,
+	}
+
+	var encoded [4][]byte
+	for i, value := range []interface{}{conf, models, decks, dconf} {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		encoded[i] = data
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO col (id, crt, mod, scm, ver, dty, usn, ls, conf, models, decks, dconf, tags)
+		VALUES (1, ?1, ?2, ?2, 11, 0, 0, 0, ?3, ?4, ?5, ?6, '{}')`,
+		now.Unix(),
+		now.UnixMilli(),
+		string(encoded[0]),
+		string(encoded[1]),
+		string(encoded[2]),
+		string(encoded[3]))
+	return err
+}
+
+func ankiDeck(id int64, name string, now time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"id":        id,
+		"name":      name,
+		"desc":      "",
+		"mod":       now.Unix(),
+		"usn":       -1,
+		"conf":      1,
+		"dyn":       0,
+		"collapsed": false,
+		"extendNew": 10,
+		"extendRev": 50,
+		"newToday":  []int{0, 0},
+		"revToday":  []int{0, 0},
+		"lrnToday":  []int{0, 0},
+		"timeToday": []int{0, 0},
+	}
+}
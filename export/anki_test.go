@@ -0,0 +1,154 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rodatboat/go-vocab/model"
+)
+
+func TestWriteAnki(t *testing.T) {
+	questions := []model.Question{
+		{
+			ID:              1,
+			QuestionType:    "F",
+			QuestionContext: "The <b>gift</b> was an endowment & more.",
+			Question:        "An endowment is:",
+			// As the LLM wrote it, the back shows the accepted choice.
+			Answer:     "a gfit",
+			AnswerKey:  "a1",
+			Choices:    []model.QuestionChoices{{Key: "a0", Value: "a loan"}, {Key: "a1", Value: "a gift"}},
+			IsCorrect:  true,
+			TargetWord: "endowment",
+		},
+		{
+			QuestionType:    "T",
+			QuestionContext: "She was ______ by the news.",
+			Question:        "Spell the word:",
+			Answer:          "stupefied",
+			TargetWord:      "stupefied",
+		},
+		// Answered wrong, so the answer is not known.
+		{QuestionType: "S", Question: "abate means:", Answer: "grow", TargetWord: "abate"},
+		// Image questions have no text answer, even once answered.
+		{QuestionType: "I", Question: "choose the best picture for surgery", Answer: "scalpel", IsCorrect: true, AnswerKey: "k"},
+	}
+
+	var buf bytes.Buffer
+	explanations := map[int]model.Explanation{
+		1: {QuestionID: 1, Definition: "a gift of money", Explanation: "Endowments are <given>.", Mnemonic: "end owed"},
+	}
+	notes, err := WriteAnki(context.Background(), &buf, "Vocab", questions, explanations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if notes != 2 {
+		t.Errorf("exported %d notes, want 2", notes)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := map[string][]byte{}
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries[file.Name] = data
+	}
+	if string(entries["media"]) != "{}" {
+		t.Errorf("unexpected media manifest %q", entries["media"])
+	}
+
+	path := filepath.Join(t.TempDir(), "collection.anki2")
+	if err := os.WriteFile(path, entries["collection.anki2"], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	conn, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	var modelsJson, decksJson string
+	if err := conn.QueryRow(`SELECT models, decks FROM col`).Scan(&modelsJson, &decksJson); err != nil {
+		t.Fatal(err)
+	}
+	var models map[string]ankiModel
+	if err := json.Unmarshal([]byte(modelsJson), &models); err != nil {
+		t.Fatal(err)
+	}
+	if len(models) != 2 || len(models["1706515200002"].Flds) != 3 {
+		t.Errorf("unexpected note types: %s", modelsJson)
+	}
+	if !strings.Contains(decksJson, `"name":"Vocab"`) {
+		t.Errorf("deck missing from %s", decksJson)
+	}
+
+	rows, err := conn.Query(`SELECT mid, tags, flds, sfld FROM notes ORDER BY id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	type note struct {
+		mid              int64
+		tags, flds, sfld string
+	}
+	var got []note
+	for rows.Next() {
+		var n note
+		if err := rows.Scan(&n.mid, &n.tags, &n.flds, &n.sfld); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, n)
+	}
+	if len(got) != 2 {
+		t.Fatalf("want 2 notes, got %+v", got)
+	}
+
+	multipleChoice := got[0]
+	if multipleChoice.mid != multipleChoiceModelId || multipleChoice.tags != " type::F word::endowment " {
+		t.Errorf("unexpected multiple choice note %+v", multipleChoice)
+	}
+	wantFields := "The &lt;b&gt;gift&lt;/b&gt; was an endowment &amp; more.\x1fAn endowment is:\x1fa gift" +
+		"\x1f<b>a gift of money</b><br>Endowments are &lt;given&gt;.<br><i>end owed</i>"
+	if multipleChoice.flds != wantFields || multipleChoice.sfld != "An endowment is:" {
+		t.Errorf("fields = %q, sort field %q", multipleChoice.flds, multipleChoice.sfld)
+	}
+
+	spelling := got[1]
+	if spelling.mid != spellingModelId || spelling.flds != "She was ______ by the news.\x1fstupefied\x1f" {
+		t.Errorf("unexpected spelling note %+v", spelling)
+	}
+
+	var cards int
+	if err := conn.QueryRow(`SELECT COUNT(*) FROM cards WHERE did = ?`, deckId("Vocab")).Scan(&cards); err != nil {
+		t.Fatal(err)
+	}
+	if cards != 2 {
+		t.Errorf("want 2 cards in deck, got %d", cards)
+	}
+}
+
+func TestWriteAnkiNothingToExport(t *testing.T) {
+	_, err := WriteAnki(context.Background(), io.Discard, "Vocab", []model.Question{{QuestionType: "S"}}, nil)
+	if !errors.Is(err, ErrNothingToExport) {
+		t.Errorf("want ErrNothingToExport, got %v", err)
+	}
+}
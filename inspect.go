@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/rodatboat/go-vocab/db"
+	"github.com/rodatboat/go-vocab/model"
+	"github.com/rodatboat/go-vocab/utils"
+)
+
+const inspectUsage = `usage: go-vocab inspect question [flags] ID
+       go-vocab inspect response [flags] FILE
+
+  question   print a stored question, every attempt at it and its
+             explanation
+  response   parse a saved start.json or next.json body the way practice
+             does and print the question, without touching the store
+`
+
+func runInspect(globals, args []string) int {
+	// Both subcommands take the same flags, so either can show the help.
+	if len(args) > 0 && isHelpFlag(args[0]) {
+		args = append([]string{"question"}, args...)
+	}
+	if len(args) == 0 || (args[0] != "question" && args[0] != "response") {
+		fmt.Fprint(os.Stderr, inspectUsage)
+		return exitUsage
+	}
+
+	flags := newCommandFlags("inspect "+args[0], inspectUsage, globals)
+	withHtml := flags.Bool("html", false, "include the slide HTML, raw and decoded")
+	if err := flags.Parse(args[1:]); err != nil {
+		return parseExitCode(err)
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return exitUsage
+	}
+	conf, err := flags.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	switch args[0] {
+	case "question":
+		id, err := strconv.Atoi(flags.Arg(0))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: question ID must be a number, got %q\n", flags.Arg(0))
+			return exitUsage
+		}
+
+		ctx := context.Background()
+		store, err := db.Open(ctx, conf.StoreDSN())
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error opening store:", err)
+			return exitFailure
+		}
+		defer store.Close()
+
+		question, err := store.GetQuestion(ctx, id)
+		if errors.Is(err, db.ErrNotFound) {
+			fmt.Fprintf(os.Stderr, "Error: no question with ID %d\n", id)
+			return exitFailure
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error reading question:", err)
+			return exitFailure
+		}
+		attempts, err := store.ListAttempts(ctx, db.AttemptFilter{QuestionID: id})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error listing attempts:", err)
+			return exitFailure
+		}
+		explanation, err := store.GetExplanation(ctx, id)
+		if err != nil && !errors.Is(err, db.ErrNotFound) {
+			fmt.Fprintln(os.Stderr, "Error reading explanation:", err)
+			return exitFailure
+		}
+
+		if err := printQuestion(os.Stdout, question, *withHtml); err != nil {
+			fmt.Fprintln(os.Stderr, "Error encoding question:", err)
+			return exitFailure
+		}
+		printAttempts(os.Stdout, attempts)
+		printExplanation(os.Stdout, explanation)
+	case "response":
+		body, err := os.ReadFile(flags.Arg(0))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error reading response:", err)
+			return exitFailure
+		}
+		data, err := utils.DecodeChallengeResponse(body, "secret")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error decoding response:", err)
+			return exitFailure
+		}
+		question, _, err := utils.ExtractQuestion(data)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error parsing question:", err)
+			return exitFailure
+		}
+		if err := printQuestion(os.Stdout, question, *withHtml); err != nil {
+			fmt.Fprintln(os.Stderr, "Error encoding question:", err)
+			return exitFailure
+		}
+	}
+	return exitOK
+}
+
+// The slide HTML dwarfs everything else, so it is left out unless asked for.
+func printQuestion(w io.Writer, question *model.Question, withHtml bool) error {
+	if !withHtml {
+		shown := *question
+		shown.Code, shown.DecodedCode = "", ""
+		question = &shown
+	}
+	return printJson(w, question)
+}
+
+func printJson(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func printAttempts(w io.Writer, attempts []model.Attempt) {
+	if len(attempts) == 0 {
+		fmt.Fprintln(w, "\nNo attempts.")
+		return
+	}
+	fmt.Fprintln(w)
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "ATTEMPT\tANSWERED AT\tCHOICE\tCORRECT\tPOINTS\tTIME\tSESSION")
+	for _, attempt := range attempts {
+		fmt.Fprintf(table, "%d\t%s\t%s\t%t\t%d\t%dms\t%s\n",
+			attempt.ID, attempt.CreatedAt.Local().Format("2006-01-02 15:04:05"), attempt.ChoiceValue,
+			attempt.IsCorrect, attempt.Points+attempt.Bonus, attempt.ResponseTime, attempt.SessionId)
+	}
+	table.Flush()
+}
+
+func printExplanation(w io.Writer, explanation *model.Explanation) {
+	if explanation == nil {
+		return
+	}
+	fmt.Fprintf(w, "\nExplained by %s on %s:\n", explanation.Model, explanation.CreatedAt.Local().Format("2006-01-02"))
+	fmt.Fprintln(w, "Definition:", explanation.Definition)
+	fmt.Fprintln(w, explanation.Explanation)
+	fmt.Fprintln(w, "Mnemonic:", explanation.Mnemonic)
+}
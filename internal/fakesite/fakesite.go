@@ -0,0 +1,358 @@
+// Package fakesite plays the part of vocabulary.com's challenge API in
+// tests. It serves the questions of the example/ fixtures from an httptest
+// server and keeps one practice session: the secret changes with every
+// response, progress grows with every answer, and a round ends with
+// RestartChallengeException like on the real site.
+package fakesite
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Danny-Dasilva/CycleTLS/cycletls"
+	"github.com/rodatboat/go-vocab/model"
+	"github.com/rodatboat/go-vocab/utils"
+)
+
+const RESTART_CHALLENGE = "RestartChallengeException"
+
+// The cookie that makes a request logged in, whatever its value.
+const SESSION_COOKIE = "JSESSIONID"
+
+const DEFAULT_ROUND_LENGTH = 10
+
+type question struct {
+	challenge model.ChallengeQuestion
+	parsed    model.Question
+}
+
+// Site is a running fake. It is also a Transport: Do sends requests for
+// https://www.vocabulary.com/... to the fake instead.
+type Site struct {
+	Server *httptest.Server
+	// Questions per round. The answer to the last one is refused with
+	// RestartChallengeException, so the round ends while a question is
+	// open. Set before the first request.
+	RoundLength int
+
+	questions []question
+	pdata     *model.PlayerData
+
+	mu sync.Mutex
+	// Empty until a round is started, and again once it is over.
+	secret  string
+	secrets int
+	// Index into questions, and its number in the round.
+	current      int
+	asked        int
+	awaitingNext bool
+	rounds       int
+	game         model.Game
+}
+
+// New starts a site serving the questions in exampleDir, the repo's example/
+// directory. Fixtures that do not parse, or have no choices to pick, are
+// left out.
+func New(exampleDir string) (*Site, error) {
+	s := &Site{RoundLength: DEFAULT_ROUND_LENGTH, current: -1}
+	if err := s.load(exampleDir); err != nil {
+		return nil, err
+	}
+	if len(s.questions) == 0 {
+		return nil, fmt.Errorf("no questions in %s", exampleDir)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/auth/me.json", s.me)
+	mux.HandleFunc("/challenge/start.json", s.challenge(s.start))
+	mux.HandleFunc("/challenge/saveanswer.json", s.challenge(s.saveAnswer))
+	mux.HandleFunc("/challenge/nextquestion.json", s.challenge(s.nextQuestion))
+	s.Server = httptest.NewServer(mux)
+	return s, nil
+}
+
+func (s *Site) Close() {
+	s.Server.Close()
+}
+
+// Reads the question of every API envelope, and wraps each challenge type
+// slide in a question using the type letter from its file name.
+func (s *Site) load(dir string) error {
+	envelopes, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, path := range envelopes {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var data model.ChallengeResponse
+		if err := json.Unmarshal(raw, &data); err != nil {
+			return fmt.Errorf("decoding %s: %w", path, err)
+		}
+		if data.PData != nil && s.pdata == nil {
+			s.pdata = data.PData
+		}
+		if data.Question != nil {
+			s.add(*data.Question)
+		}
+	}
+
+	slides, err := filepath.Glob(filepath.Join(dir, "challenge types", "*.html"))
+	if err != nil {
+		return err
+	}
+	for _, path := range slides {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		base := filepath.Base(path)
+		s.add(model.ChallengeQuestion{
+			Type: strings.SplitN(base, "-", 2)[0],
+			Code: base64.StdEncoding.EncodeToString(raw),
+		})
+	}
+	return nil
+}
+
+func (s *Site) add(challenge model.ChallengeQuestion) {
+	parsed, _, err := utils.ExtractQuestion(&model.ChallengeResponse{Secret: "fixture", Question: &challenge})
+	if err != nil || len(parsed.Choices) == 0 {
+		return
+	}
+	s.questions = append(s.questions, question{challenge: challenge, parsed: *parsed})
+}
+
+// CorrectKey is the key of the choice the site takes as right for q: always
+// the first one, the fixtures do not say.
+func CorrectKey(q model.Question) string {
+	return q.Choices[0].Key
+}
+
+// Rounds is the number of rounds started so far.
+func (s *Site) Rounds() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rounds
+}
+
+// Game is the played, correct and points counters of the current round.
+func (s *Site) Game() model.Game {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.game
+}
+
+func loggedIn(r *http.Request) bool {
+	cookie, err := r.Cookie(SESSION_COOKIE)
+	return err == nil && cookie.Value != ""
+}
+
+func (s *Site) me(w http.ResponseWriter, r *http.Request) {
+	var me model.MeResponse
+	me.Auth.LoggedIn = loggedIn(r)
+	if me.Auth.LoggedIn {
+		me.Auth.Nickname = "Fake S."
+	}
+	writeJson(w, http.StatusOK, me)
+}
+
+// challenge checks what every challenge endpoint needs and serializes them,
+// since they share the session.
+func (s *Site) challenge(handle func(form url.Values) (int, *model.ChallengeResponse)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !loggedIn(r) {
+			http.Error(w, "not logged in", http.StatusUnauthorized)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := checkForm(r.URL.Path, r.PostForm); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		status, resp := handle(r.PostForm)
+		s.mu.Unlock()
+		writeJson(w, status, resp)
+	}
+}
+
+// The form keys each challenge endpoint takes, and whether they are
+// required. Keys are case sensitive, anything else is refused.
+var formKeys = map[string]map[string]bool{
+	"/challenge/start.json":        {"v": true, "activitytype": false, "wordlistid": false, "secret": false},
+	"/challenge/saveanswer.json":   {"v": true, "secret": true, "rt": true, "a": true},
+	"/challenge/nextquestion.json": {"v": true, "secret": true},
+}
+
+func checkForm(path string, form url.Values) error {
+	keys := formKeys[path]
+	for key := range form {
+		if _, ok := keys[key]; !ok {
+			return fmt.Errorf("unexpected form key %q", key)
+		}
+	}
+	for key, required := range keys {
+		if required && form.Get(key) == "" {
+			return fmt.Errorf("missing form key %q", key)
+		}
+	}
+	return nil
+}
+
+func restart() (int, *model.ChallengeResponse) {
+	return http.StatusBadRequest, &model.ChallengeResponse{Error: RESTART_CHALLENGE}
+}
+
+// A start with the current secret resumes the round at its open question,
+// with any other secret the round is over. Without one a new round starts.
+func (s *Site) start(form url.Values) (int, *model.ChallengeResponse) {
+	if secret := form.Get("secret"); secret != "" {
+		if secret != s.secret {
+			return restart()
+		}
+		if s.awaitingNext {
+			return s.nextQuestion(form)
+		}
+		return http.StatusOK, s.questionResponse("resume")
+	}
+
+	listId, _ := strconv.Atoi(form.Get("wordlistid"))
+	s.rounds++
+	s.asked = 1
+	s.awaitingNext = false
+	s.game = model.Game{WordListId: listId, Name: "fake list", Type: "p"}
+	s.next()
+	return http.StatusOK, s.questionResponse("newround")
+}
+
+func (s *Site) saveAnswer(form url.Values) (int, *model.ChallengeResponse) {
+	if s.secret == "" || form.Get("secret") != s.secret || s.awaitingNext {
+		return restart()
+	}
+	if s.asked >= s.RoundLength {
+		s.secret = ""
+		return restart()
+	}
+
+	q := s.questions[s.current].parsed
+	correct := form.Get("a") == CorrectKey(q)
+	answer := &model.AnswerResult{Correct: correct, Word: q.TargetWord}
+	s.game.Played++
+	if correct {
+		answer.Points = q.PointValue
+		s.game.Correct++
+		s.game.Points += answer.Points
+	}
+	s.game.Progress = float64(s.asked) / float64(s.RoundLength)
+	s.awaitingNext = true
+
+	game := s.game
+	return http.StatusOK, &model.ChallengeResponse{
+		V:      3,
+		Answer: answer,
+		Game:   &game,
+		Secret: s.rotate(),
+	}
+}
+
+func (s *Site) nextQuestion(form url.Values) (int, *model.ChallengeResponse) {
+	if s.secret == "" || form.Get("secret") != s.secret || !s.awaitingNext {
+		return restart()
+	}
+	s.asked++
+	s.awaitingNext = false
+	s.next()
+	return http.StatusOK, s.questionResponse("next")
+}
+
+// Moves on to the next fixture, starting over after the last.
+func (s *Site) next() {
+	s.current = (s.current + 1) % len(s.questions)
+}
+
+func (s *Site) rotate() string {
+	s.secrets++
+	s.secret = fmt.Sprintf("fake-secret-%d", s.secrets)
+	return s.secret
+}
+
+func (s *Site) questionResponse(action string) *model.ChallengeResponse {
+	challenge := s.questions[s.current].challenge
+	game := s.game
+	return &model.ChallengeResponse{
+		V:        3,
+		Question: &challenge,
+		Round:    &model.Round{Number: s.rounds, PlayedCount: s.game.Played},
+		Action:   action,
+		PData:    s.pdata,
+		Game:     &game,
+		Secret:   s.rotate(),
+	}
+}
+
+func writeJson(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// Do sends a request meant for vocabulary.com to the fake, keeping the path
+// and query.
+func (s *Site) Do(rawURL string, options cycletls.Options, method string) (cycletls.Response, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return cycletls.Response{}, err
+	}
+	server, _ := url.Parse(s.Server.URL)
+	target.Scheme = server.Scheme
+	target.Host = server.Host
+
+	req, err := http.NewRequest(method, target.String(), strings.NewReader(options.Body))
+	if err != nil {
+		return cycletls.Response{}, err
+	}
+	for name, value := range options.Headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := s.Server.Client().Do(req)
+	if err != nil {
+		return cycletls.Response{}, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return cycletls.Response{}, err
+	}
+
+	headers := map[string]string{}
+	for name := range resp.Header {
+		headers[name] = resp.Header.Get(name)
+	}
+	return cycletls.Response{
+		Status:   resp.StatusCode,
+		Body:     string(body),
+		Headers:  headers,
+		Cookies:  resp.Cookies(),
+		FinalUrl: rawURL,
+	}, nil
+}
@@ -0,0 +1,130 @@
+package fakesite
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Danny-Dasilva/CycleTLS/cycletls"
+	"github.com/rodatboat/go-vocab/model"
+	"github.com/rodatboat/go-vocab/utils"
+)
+
+func newSite(t *testing.T, roundLength int) *Site {
+	t.Helper()
+	site, err := New("../../example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	site.RoundLength = roundLength
+	t.Cleanup(site.Close)
+	return site
+}
+
+// post sends a form to path as the logged in session does.
+func post(t *testing.T, site *Site, path string, form url.Values) (int, *model.ChallengeResponse) {
+	t.Helper()
+	resp, err := site.Do("https://www.vocabulary.com"+path, cycletls.Options{
+		Body: form.Encode(),
+		Headers: map[string]string{
+			"Content-Type": "application/x-www-form-urlencoded",
+			"Cookie":       "JSESSIONID=session;guid=g;",
+		},
+	}, "POST")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data model.ChallengeResponse
+	if strings.HasPrefix(resp.Headers["Content-Type"], "application/json") {
+		if err := json.Unmarshal([]byte(resp.Body), &data); err != nil {
+			t.Fatalf("%s: %v\n%s", path, err, resp.Body)
+		}
+	}
+	return resp.Status, &data
+}
+
+func TestLogin(t *testing.T) {
+	site := newSite(t, 3)
+	resp, err := site.Do("https://www.vocabulary.com/auth/me.json", cycletls.Options{}, "GET")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var me model.MeResponse
+	if err := json.Unmarshal([]byte(resp.Body), &me); err != nil || me.Auth.LoggedIn {
+		t.Errorf("me without a session = %s (%v)", resp.Body, err)
+	}
+
+	resp, err = site.Do("https://www.vocabulary.com/challenge/start.json", cycletls.Options{}, "POST")
+	if err != nil || resp.Status != 401 {
+		t.Errorf("start without a session = %d (%v)", resp.Status, err)
+	}
+}
+
+func TestRound(t *testing.T) {
+	site := newSite(t, 2)
+
+	status, data := post(t, site, "/challenge/start.json", url.Values{"v": {"3"}, "wordlistid": {"7"}})
+	if status != 200 || data.Action != "newround" || data.Game.WordListId != 7 {
+		t.Fatalf("start = %d %+v", status, data)
+	}
+	question, secret, err := utils.ExtractQuestion(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, data = post(t, site, "/challenge/saveanswer.json", url.Values{"v": {"3"}, "rt": {"1200"}, "secret": {secret}, "a": {CorrectKey(*question)}})
+	if status != 200 || !data.Answer.Correct || data.Game.Progress != 0.5 || data.Secret == secret {
+		t.Fatalf("saveanswer = %d %+v", status, data)
+	}
+	if status, _ := post(t, site, "/challenge/nextquestion.json", url.Values{"v": {"3"}, "secret": {secret}}); status != 400 {
+		t.Errorf("nextquestion with a used secret = %d", status)
+	}
+
+	status, data = post(t, site, "/challenge/nextquestion.json", url.Values{"v": {"3"}, "secret": {data.Secret}})
+	if status != 200 || data.Action != "next" {
+		t.Fatalf("nextquestion = %d %+v", status, data)
+	}
+	next, secret, err := utils.ExtractQuestion(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.Code == question.Code {
+		t.Error("nextquestion served the same question")
+	}
+
+	status, data = post(t, site, "/challenge/saveanswer.json", url.Values{"v": {"3"}, "rt": {"1200"}, "secret": {secret}, "a": {"wrong"}})
+	if status != 400 || data.Error != RESTART_CHALLENGE {
+		t.Errorf("last answer of the round = %d %+v", status, data)
+	}
+	if status, data = post(t, site, "/challenge/start.json", url.Values{"v": {"3"}, "secret": {secret}}); status != 400 || data.Error != RESTART_CHALLENGE {
+		t.Errorf("resuming a finished round = %d %+v", status, data)
+	}
+
+	if status, _ = post(t, site, "/challenge/start.json", url.Values{"v": {"3"}, "wordlistid": {"7"}}); status != 200 || site.Rounds() != 2 {
+		t.Errorf("second round = %d, %d rounds", status, site.Rounds())
+	}
+	if game := site.Game(); game.Played != 0 {
+		t.Errorf("new round starts from %+v", game)
+	}
+}
+
+func TestFormKeys(t *testing.T) {
+	site := newSite(t, 3)
+	for _, test := range []struct {
+		path string
+		form url.Values
+	}{
+		{"/challenge/start.json", url.Values{"V": {"3"}, "WordListId": {"7"}}},
+		{"/challenge/start.json", url.Values{"v": {"3"}, "list": {"7"}}},
+		{"/challenge/saveanswer.json", url.Values{"v": {"3"}, "secret": {"s"}, "rt": {"1"}}},
+		{"/challenge/nextquestion.json", url.Values{"v": {"3"}, "Secret": {"s"}}},
+	} {
+		if status, _ := post(t, site, test.path, test.form); status != 400 {
+			t.Errorf("%s with %v = %d, want 400", test.path, test.form, status)
+		}
+	}
+	if site.Rounds() != 0 {
+		t.Error("a round was started with a bad form")
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/rodatboat/go-vocab/db"
+	"github.com/rodatboat/go-vocab/journal"
+)
+
+const journalUsage = `usage: go-vocab journal replay [flags] FILE...
+
+  replay   save the questions and attempts of journals into the store
+`
+
+func runJournal(globals, args []string) int {
+	// journal has a single subcommand, so its help is the help of replay.
+	if len(args) > 0 && isHelpFlag(args[0]) {
+		args = append([]string{"replay"}, args...)
+	}
+	if len(args) == 0 || args[0] != "replay" {
+		fmt.Fprint(os.Stderr, journalUsage)
+		return exitUsage
+	}
+
+	flags := newCommandFlags("journal replay", journalUsage, globals)
+	if err := flags.Parse(args[1:]); err != nil {
+		return parseExitCode(err)
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return exitUsage
+	}
+	conf, err := flags.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+	store, err := db.Open(ctx, conf.StoreDSN())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error opening store:", err)
+		return exitFailure
+	}
+	defer store.Close()
+
+	replayer := journal.NewReplayer(store)
+	for _, path := range flags.Args() {
+		err := replayer.ReplayFile(ctx, path)
+		if ctx.Err() != nil {
+			fmt.Fprintln(os.Stderr, "Interrupted, exiting...")
+			return exitInterrupted
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error replaying journal:", err)
+			return exitFailure
+		}
+	}
+
+	stats := replayer.Stats
+	fmt.Printf("Replayed %d events: %d questions, %d attempts saved, %d already stored\n",
+		stats.Events, stats.Questions, stats.Attempts, stats.Skipped)
+	return exitOK
+}
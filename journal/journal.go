@@ -0,0 +1,140 @@
+// Package journal appends what happens in a practice session to a JSONL
+// file, one event per line, next to the upserts in the store. The journal
+// is append-only, so it keeps every attempt even when the database is lost,
+// and a Replayer can rebuild the question bank and attempt history from it.
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rodatboat/go-vocab/model"
+)
+
+const (
+	EVENT_SESSION_START = "session_start"
+	EVENT_QUESTION      = "question"
+	EVENT_SUGGESTION    = "suggestion"
+	EVENT_ANSWER        = "answer"
+	EVENT_RESULT        = "result"
+	EVENT_PROGRESS      = "progress"
+	EVENT_ROUND_OVER    = "round_over"
+	EVENT_ERROR         = "error"
+)
+
+// Event is one line of the journal. Which of the optional fields are set
+// depends on Type:
+//
+//	question    Question, as received
+//	suggestion  QuestionID and Choice, the LLM's answer
+//	answer      QuestionID and Choice, as submitted
+//	result      Question, answered, and Attempt
+//	progress    Progress
+//	error       Error
+type Event struct {
+	// Grows by one with every event of a journal file, across sessions.
+	Seq       int64     `json:"seq"`
+	Time      time.Time `json:"time"`
+	Type      string    `json:"type"`
+	SessionId string    `json:"session_id"`
+	ListId    int       `json:"list_id,omitempty"`
+
+	QuestionID int                    `json:"question_id,omitempty"`
+	Question   *model.Question        `json:"question,omitempty"`
+	Choice     *model.QuestionChoices `json:"choice,omitempty"`
+	Attempt    *model.Attempt         `json:"attempt,omitempty"`
+	Progress   float64                `json:"progress,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+}
+
+// Writer appends events to a journal file.
+type Writer struct {
+	mu   sync.Mutex
+	file *os.File
+	seq  int64
+}
+
+// Open opens the journal at path for appending, creating it if needed. The
+// sequence carries on from the last event already in the file.
+func Open(path string) (*Writer, error) {
+	w := &Writer{}
+	err := ReadFile(path, func(event Event) error {
+		w.seq = event.Seq
+		return nil
+	})
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("opening journal: %w", err)
+	}
+	w.file = file
+	return w, nil
+}
+
+// Append sets the event's Seq and, when zero, its Time, and writes it as one
+// line.
+func (w *Writer) Append(event Event) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.seq++
+	event.Seq = w.seq
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	line, err := json.Marshal(event)
+	if err != nil {
+		w.seq--
+		return err
+	}
+	if _, err := w.file.Write(append(line, '\n')); err != nil {
+		w.seq--
+		return fmt.Errorf("writing journal: %w", err)
+	}
+	return nil
+}
+
+func (w *Writer) Close() error {
+	return w.file.Close()
+}
+
+// Read calls fn with every event of r, in order.
+func Read(r io.Reader, fn func(Event) error) error {
+	scanner := bufio.NewScanner(r)
+	// Questions carry their slide HTML, well over the default 64KB at times.
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return fmt.Errorf("journal line %d: %w", line, err)
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func ReadFile(path string, fn func(Event) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := Read(file, fn); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
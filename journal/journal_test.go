@@ -0,0 +1,135 @@
+package journal
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rodatboat/go-vocab/db"
+	"github.com/rodatboat/go-vocab/model"
+)
+
+func readAll(t *testing.T, path string) []Event {
+	t.Helper()
+	var events []Event
+	if err := ReadFile(path, func(event Event) error {
+		events = append(events, event)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return events
+}
+
+func TestSequenceContinues(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	for session := 0; session < 2; session++ {
+		w, err := Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, eventType := range []string{EVENT_SESSION_START, EVENT_PROGRESS} {
+			if err := w.Append(Event{Type: eventType}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	events := readAll(t, path)
+	if len(events) != 4 {
+		t.Fatalf("events = %+v", events)
+	}
+	for i, event := range events {
+		if event.Seq != int64(i+1) || event.Time.IsZero() {
+			t.Errorf("event %d = %+v", i, event)
+		}
+	}
+}
+
+// A session of one question answered wrong, then right.
+func writeSession(t *testing.T, path string) {
+	t.Helper()
+	w, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	question := model.Question{
+		ID:           7,
+		QuestionType: "S",
+		Question:     "a word for scattered",
+		TargetWord:   "diffused",
+		WordID:       3,
+		Choices:      []model.QuestionChoices{{Key: "a", Value: "dispersed"}, {Key: "b", Value: "gathered"}},
+	}
+	answered := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	events := []Event{
+		{Type: EVENT_SESSION_START, SessionId: "s1"},
+		{Type: EVENT_QUESTION, SessionId: "s1", QuestionID: 7, Question: &question},
+		{Type: EVENT_ANSWER, SessionId: "s1", QuestionID: 7, Choice: &question.Choices[1]},
+	}
+	wrong := question
+	attempt := model.Attempt{ID: 40, QuestionID: 7, ChoiceKey: "b", SessionId: "s1", CreatedAt: answered}
+	events = append(events, Event{Type: EVENT_RESULT, SessionId: "s1", Question: &wrong, Attempt: &attempt})
+
+	right := question
+	right.Answer, right.AnswerKey, right.IsCorrect = "dispersed", "a", true
+	second := model.Attempt{ID: 41, QuestionID: 7, ChoiceKey: "a", IsCorrect: true, Points: 10, SessionId: "s1", CreatedAt: answered.Add(5 * time.Second)}
+	events = append(events,
+		Event{Type: EVENT_RESULT, SessionId: "s1", Question: &right, Attempt: &second},
+		Event{Type: EVENT_ROUND_OVER, SessionId: "s1", Error: "RestartChallengeException"},
+	)
+	for _, event := range events {
+		if err := w.Append(event); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReplay(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	writeSession(t, path)
+
+	store := db.NewMemoryStore()
+	replayer := NewReplayer(store)
+	if err := replayer.ReplayFile(ctx, path); err != nil {
+		t.Fatal(err)
+	}
+	if replayer.Stats != (ReplayStats{Events: 6, Questions: 1, Attempts: 2}) {
+		t.Errorf("stats = %+v", replayer.Stats)
+	}
+
+	questions, err := store.ListQuestions(ctx, db.QuestionFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(questions) != 1 || !questions[0].IsCorrect || questions[0].AnswerKey != "a" {
+		t.Fatalf("questions = %+v", questions)
+	}
+	attempts, err := store.ListAttempts(ctx, db.AttemptFilter{QuestionID: questions[0].ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attempts) != 2 || attempts[0].SessionId != "s1" || attempts[1].Points != 10 {
+		t.Errorf("attempts = %+v", attempts)
+	}
+	word, err := store.GetWord(ctx, "diffused")
+	if err != nil || word.TimesAsked != 2 || word.TimesCorrect != 1 {
+		t.Errorf("word = %+v (%v)", word, err)
+	}
+
+	// A second replay, e.g. of overlapping journals, adds nothing.
+	again := NewReplayer(store)
+	if err := again.ReplayFile(ctx, path); err != nil {
+		t.Fatal(err)
+	}
+	if again.Stats.Attempts != 0 || again.Stats.Skipped != 2 {
+		t.Errorf("stats of the second replay = %+v", again.Stats)
+	}
+}
@@ -0,0 +1,117 @@
+package journal
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rodatboat/go-vocab/db"
+	"github.com/rodatboat/go-vocab/model"
+)
+
+// ReplayStats counts what a replay wrote to the store.
+type ReplayStats struct {
+	Events    int
+	Questions int
+	Attempts  int
+	// Attempts already in the store, e.g. from an earlier replay.
+	Skipped int
+}
+
+// Replayer saves the questions and attempts of journals into a store. Questions
+// are upserted like the Runner does, so replaying into a store that has them
+// only fills in answers. Attempts already in the store are skipped, which
+// makes replaying the same journal twice harmless.
+type Replayer struct {
+	store db.QuestionStore
+	// Journal question ids to store ids.
+	ids   map[int]int
+	Stats ReplayStats
+}
+
+func NewReplayer(store db.QuestionStore) *Replayer {
+	return &Replayer{store: store, ids: map[int]int{}}
+}
+
+func (r *Replayer) Apply(ctx context.Context, event Event) error {
+	r.Stats.Events++
+	switch event.Type {
+	case EVENT_QUESTION:
+		if event.Question == nil {
+			return nil
+		}
+		_, err := r.saveQuestion(ctx, *event.Question)
+		return err
+	case EVENT_RESULT:
+		if event.Question == nil || event.Attempt == nil {
+			return fmt.Errorf("result event %d without question or attempt", event.Seq)
+		}
+		id, err := r.saveQuestion(ctx, *event.Question)
+		if err != nil {
+			return err
+		}
+		attempt := *event.Attempt
+		attempt.ID = 0
+		attempt.QuestionID = id
+		if attempt.CreatedAt.IsZero() {
+			attempt.CreatedAt = event.Time
+		}
+		return r.saveAttempt(ctx, attempt)
+	}
+	return nil
+}
+
+func (r *Replayer) saveQuestion(ctx context.Context, question model.Question) (int, error) {
+	journalId := question.ID
+	question.ID = 0
+	question.WordID = 0
+	id, err := r.store.SaveQuestion(ctx, question)
+	if err != nil {
+		return 0, fmt.Errorf("saving question: %w", err)
+	}
+	if _, seen := r.ids[journalId]; !seen {
+		r.Stats.Questions++
+	}
+	r.ids[journalId] = id
+	return id, nil
+}
+
+func (r *Replayer) saveAttempt(ctx context.Context, attempt model.Attempt) error {
+	existing, err := r.store.ListAttempts(ctx, db.AttemptFilter{
+		QuestionID: attempt.QuestionID,
+		SessionId:  attempt.SessionId,
+	})
+	if err != nil {
+		return fmt.Errorf("listing attempts: %w", err)
+	}
+	for _, saved := range existing {
+		if sameAttempt(saved, attempt) {
+			r.Stats.Skipped++
+			return nil
+		}
+	}
+	if _, err := r.store.SaveAttempt(ctx, attempt); err != nil {
+		return fmt.Errorf("saving attempt: %w", err)
+	}
+	r.Stats.Attempts++
+	return nil
+}
+
+// Stores round created_at differently, and a session never answers the same
+// question twice within a second.
+func sameAttempt(a, b model.Attempt) bool {
+	return a.ChoiceKey == b.ChoiceKey && a.CreatedAt.Sub(b.CreatedAt).Abs() < time.Second
+}
+
+// ReplayFile applies every event of the journal at path.
+func (r *Replayer) ReplayFile(ctx context.Context, path string) error {
+	return ReadFile(path, func(event Event) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := r.Apply(ctx, event); err != nil {
+			return fmt.Errorf("event %d: %w", event.Seq, err)
+		}
+		return nil
+	})
+}
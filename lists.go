@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/rodatboat/go-vocab/db"
+	"github.com/rodatboat/go-vocab/model"
+)
+
+const listsUsage = `usage: go-vocab lists [flags]
+
+Shows your level and word lists as of the latest practice session.
+
+vocabulary.com sends them with every question, and practice saves them to
+the store each time, so this makes no request of its own.
+`
+
+func runLists(globals, args []string) int {
+	flags := newCommandFlags("lists", listsUsage, globals)
+	asJson := flags.Bool("json", false, "print the snapshot as JSON")
+	if err := flags.Parse(args); err != nil {
+		return parseExitCode(err)
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return exitUsage
+	}
+	conf, err := flags.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	ctx := context.Background()
+	store, err := db.Open(ctx, conf.StoreDSN())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error opening store:", err)
+		return exitFailure
+	}
+	defer store.Close()
+
+	snapshots, err := store.ListAccountSnapshots(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error reading lists:", err)
+		return exitFailure
+	}
+	if len(snapshots) == 0 {
+		fmt.Fprintln(os.Stderr, "No lists saved yet, run `go-vocab practice` first")
+		return exitFailure
+	}
+	// Oldest first.
+	snapshot := snapshots[len(snapshots)-1]
+
+	if *asJson {
+		if err := printJson(os.Stdout, snapshot); err != nil {
+			fmt.Fprintln(os.Stderr, "Error encoding snapshot:", err)
+			return exitFailure
+		}
+		return exitOK
+	}
+	printAccountSnapshot(os.Stdout, &snapshot)
+	return exitOK
+}
+
+func printAccountSnapshot(w io.Writer, snapshot *model.AccountSnapshot) {
+	fmt.Fprintf(w, "Level:    %s (%s), %d%% to next level\n", snapshot.Level.Name, snapshot.Level.ID, snapshot.Level.Progress)
+	fmt.Fprintf(w, "Points:   %d\n", snapshot.Points)
+	fmt.Fprintf(w, "Played:   %d\n", snapshot.NumPlayed)
+	fmt.Fprintf(w, "Mastered: %d\n\n", snapshot.NumMastered)
+
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "LIST ID\tNAME\tWORDS\tPROGRESS\tPRIORITY\tCURRENT")
+	for _, list := range snapshot.Lists {
+		current := ""
+		if list.Current {
+			current = "*"
+		}
+		fmt.Fprintf(table, "%d\t%s\t%d\t%.0f%%\t%d\t%s\n",
+			list.ID, list.Name, list.WordCount, list.CompletionPercentage*100, list.Priority, current)
+	}
+	table.Flush()
+}
@@ -0,0 +1,136 @@
+// Package llm talks to local language model servers: Ollama through its
+// generate and chat endpoints, and anything serving the OpenAI chat
+// completions API, such as llama.cpp server or vLLM.
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Replies larger than this are cut off before decoding.
+const maxReplySize = 4 << 20
+
+// The server answered, but without any text.
+var ErrEmptyReply = errors.New("empty reply")
+
+// Request is one prompt. When Schema is set the model is constrained to
+// reply with JSON matching it.
+type Request struct {
+	System string
+	Prompt string
+	Schema json.RawMessage
+}
+
+// Options shared by every backend.
+type Options struct {
+	URL         string
+	Model       string
+	Temperature float64
+	// Zero means no timeout.
+	Timeout time.Duration
+	// Sent as a bearer token when set.
+	APIKey string
+	// http.DefaultClient when nil.
+	Client *http.Client
+}
+
+// StatusError is a reply with a non-2xx status. Message is the error the
+// server gave, or the start of the body.
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("status %d: %s", e.StatusCode, e.Message)
+}
+
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+func messages(req Request) []message {
+	var msgs []message
+	if req.System != "" {
+		msgs = append(msgs, message{Role: "system", Content: req.System})
+	}
+	return append(msgs, message{Role: "user", Content: req.Prompt})
+}
+
+// post sends body as JSON to o.URL and decodes the reply into reply.
+func (o Options) post(ctx context.Context, body, reply interface{}) error {
+	if o.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.Timeout)
+		defer cancel()
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("encoding request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", o.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if o.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.APIKey)
+	}
+
+	client := o.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxReplySize))
+	if err != nil {
+		return fmt.Errorf("reading reply: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &StatusError{StatusCode: resp.StatusCode, Message: errorMessage(data)}
+	}
+	if err := json.Unmarshal(data, reply); err != nil {
+		return fmt.Errorf("decoding reply: %w", err)
+	}
+	return nil
+}
+
+// Ollama replies {"error": "..."}, OpenAI {"error": {"message": "..."}}.
+func errorMessage(body []byte) string {
+	var reply struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal(body, &reply) == nil && len(reply.Error) > 0 {
+		var text string
+		if json.Unmarshal(reply.Error, &text) == nil {
+			return text
+		}
+		var detail struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(reply.Error, &detail) == nil && detail.Message != "" {
+			return detail.Message
+		}
+	}
+
+	text := strings.TrimSpace(string(body))
+	if len(text) > 200 {
+		text = text[:200] + "..."
+	}
+	return text
+}
@@ -0,0 +1,175 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const schema = `{"type":"object","properties":{"code":{"type":"string"}}}`
+
+// standIn answers every request with reply and keeps the last request body.
+func standIn(t *testing.T, status int, reply string) (*httptest.Server, *map[string]interface{}) {
+	t.Helper()
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer key" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Errorf("request is not JSON: %v", err)
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(reply))
+	}))
+	t.Cleanup(server.Close)
+	return server, &got
+}
+
+type generator interface {
+	Generate(ctx context.Context, req Request) (string, error)
+}
+
+func TestProviders(t *testing.T) {
+	tests := []struct {
+		name  string
+		new   func(Options) generator
+		reply string
+		check func(t *testing.T, body map[string]interface{})
+	}{
+		{
+			name: "ollama generate",
+			new: func(o Options) generator {
+				return &OllamaGenerate{o}
+			},
+			reply: `{"response":"{\"code\":\"a1\"}","done":true}`,
+			check: func(t *testing.T, body map[string]interface{}) {
+				if body["system"] != "be brief" || body["prompt"] != "q \"1\"\n%s" || body["stream"] != false {
+					t.Errorf("body = %v", body)
+				}
+				if _, ok := body["format"].(map[string]interface{}); !ok {
+					t.Errorf("format = %v, want the schema", body["format"])
+				}
+				if body["options"].(map[string]interface{})["temperature"] != 0.5 {
+					t.Errorf("options = %v", body["options"])
+				}
+			},
+		},
+		{
+			name: "ollama chat",
+			new: func(o Options) generator {
+				return &OllamaChat{o}
+			},
+			reply: `{"message":{"role":"assistant","content":"{\"code\":\"a1\"}"},"done":true}`,
+			check: func(t *testing.T, body map[string]interface{}) {
+				msgs := body["messages"].([]interface{})
+				if len(msgs) != 2 || msgs[0].(map[string]interface{})["role"] != "system" {
+					t.Errorf("messages = %v", msgs)
+				}
+				if _, ok := body["format"].(map[string]interface{}); !ok {
+					t.Errorf("format = %v, want the schema", body["format"])
+				}
+			},
+		},
+		{
+			name: "openai",
+			new: func(o Options) generator {
+				return &OpenAI{Options: o}
+			},
+			reply: `{"choices":[{"index":0,"message":{"role":"assistant","content":"{\"code\":\"a1\"}"}}]}`,
+			check: func(t *testing.T, body map[string]interface{}) {
+				if body["temperature"] != 0.5 || body["model"] != "tiny" {
+					t.Errorf("body = %v", body)
+				}
+				format := body["response_format"].(map[string]interface{})
+				if format["type"] != "json_schema" {
+					t.Errorf("response_format = %v", format)
+				}
+				if msgs := body["messages"].([]interface{}); msgs[1].(map[string]interface{})["content"] != "q \"1\"\n%s" {
+					t.Errorf("messages = %v", msgs)
+				}
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, body := standIn(t, http.StatusOK, test.reply)
+			provider := test.new(Options{URL: server.URL, Model: "tiny", Temperature: 0.5, APIKey: "key"})
+
+			reply, err := provider.Generate(context.Background(), Request{
+				System: "be brief",
+				Prompt: "q \"1\"\n%s",
+				Schema: json.RawMessage(schema),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reply != `{"code":"a1"}` {
+				t.Errorf("reply = %q", reply)
+			}
+			test.check(t, *body)
+		})
+	}
+}
+
+func TestOpenAISchema(t *testing.T) {
+	for _, strict := range []bool{false, true} {
+		server, body := standIn(t, http.StatusOK, `{"choices":[{"message":{"role":"assistant","content":"{}"}}]}`)
+		provider := &OpenAI{Options: Options{URL: server.URL, APIKey: "key"}, Strict: strict}
+		if _, err := provider.Generate(context.Background(), Request{Prompt: "q", Schema: json.RawMessage(schema)}); err != nil {
+			t.Fatal(err)
+		}
+
+		format := (*body)["response_format"].(map[string]interface{})["json_schema"].(map[string]interface{})
+		if format["strict"] != strict || format["name"] != "reply" {
+			t.Errorf("json_schema with Strict %v = %v", strict, format)
+		}
+		var want interface{}
+		json.Unmarshal([]byte(schema), &want)
+		if !reflect.DeepEqual(format["schema"], want) {
+			t.Errorf("schema sent as %v, want %s", format["schema"], schema)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	ctx := context.Background()
+
+	server, _ := standIn(t, http.StatusNotFound, `{"error":"model \"tiny\" not found"}`)
+	_, err := (&OllamaGenerate{Options{URL: server.URL, APIKey: "key"}}).Generate(ctx, Request{})
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != 404 || statusErr.Message != `model "tiny" not found` {
+		t.Errorf("want ollama status error, got %v", err)
+	}
+
+	server, _ = standIn(t, http.StatusBadRequest, `{"error":{"message":"bad schema","type":"invalid_request_error"}}`)
+	_, err = (&OpenAI{Options: Options{URL: server.URL, APIKey: "key"}}).Generate(ctx, Request{})
+	if !errors.As(err, &statusErr) || statusErr.Message != "bad schema" {
+		t.Errorf("want openai status error, got %v", err)
+	}
+
+	server, _ = standIn(t, http.StatusOK, `{"choices":[]}`)
+	_, err = (&OpenAI{Options: Options{URL: server.URL, APIKey: "key"}}).Generate(ctx, Request{})
+	if !errors.Is(err, ErrEmptyReply) {
+		t.Errorf("want ErrEmptyReply, got %v", err)
+	}
+
+	done := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer slow.Close()
+	defer close(done)
+	_, err = (&OllamaChat{Options{URL: slow.URL, Timeout: 10 * time.Millisecond}}).Generate(ctx, Request{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want deadline exceeded, got %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+)
+
+type ollamaOptions struct {
+	Temperature float64 `json:"temperature"`
+}
+
+// OllamaGenerate uses Ollama's /api/generate endpoint.
+type OllamaGenerate struct {
+	Options
+}
+
+func (p *OllamaGenerate) Generate(ctx context.Context, req Request) (string, error) {
+	body := struct {
+		Model   string          `json:"model"`
+		System  string          `json:"system,omitempty"`
+		Prompt  string          `json:"prompt"`
+		Format  json.RawMessage `json:"format,omitempty"`
+		Stream  bool            `json:"stream"`
+		Options ollamaOptions   `json:"options"`
+	}{
+		Model:   p.Model,
+		System:  req.System,
+		Prompt:  req.Prompt,
+		Format:  req.Schema,
+		Options: ollamaOptions{Temperature: p.Temperature},
+	}
+
+	var reply struct {
+		Response string `json:"response"`
+	}
+	if err := p.post(ctx, body, &reply); err != nil {
+		return "", err
+	}
+	if reply.Response == "" {
+		return "", ErrEmptyReply
+	}
+	return reply.Response, nil
+}
+
+// OllamaChat uses Ollama's /api/chat endpoint, with the system prompt sent
+// as a system message.
+type OllamaChat struct {
+	Options
+}
+
+func (p *OllamaChat) Generate(ctx context.Context, req Request) (string, error) {
+	body := struct {
+		Model    string          `json:"model"`
+		Messages []message       `json:"messages"`
+		Format   json.RawMessage `json:"format,omitempty"`
+		Stream   bool            `json:"stream"`
+		Options  ollamaOptions   `json:"options"`
+	}{
+		Model:    p.Model,
+		Messages: messages(req),
+		Format:   req.Schema,
+		Options:  ollamaOptions{Temperature: p.Temperature},
+	}
+
+	var reply struct {
+		Message message `json:"message"`
+	}
+	if err := p.post(ctx, body, &reply); err != nil {
+		return "", err
+	}
+	if reply.Message.Content == "" {
+		return "", ErrEmptyReply
+	}
+	return reply.Message.Content, nil
+}
@@ -0,0 +1,59 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// OpenAI uses the /v1/chat/completions endpoint of the OpenAI API, which
+// llama.cpp server and vLLM also serve. A schema is sent as a json_schema
+// response format, in strict mode only when Strict is set: OpenAI then
+// rejects schemas whose objects allow additional properties or leave any
+// property out of required.
+type OpenAI struct {
+	Options
+	Strict bool
+}
+
+type openAIResponseFormat struct {
+	Type       string `json:"type"`
+	JSONSchema struct {
+		Name   string          `json:"name"`
+		Schema json.RawMessage `json:"schema"`
+		Strict bool            `json:"strict"`
+	} `json:"json_schema"`
+}
+
+func (p *OpenAI) Generate(ctx context.Context, req Request) (string, error) {
+	body := struct {
+		Model          string                `json:"model"`
+		Messages       []message             `json:"messages"`
+		Temperature    float64               `json:"temperature"`
+		ResponseFormat *openAIResponseFormat `json:"response_format,omitempty"`
+		Stream         bool                  `json:"stream"`
+	}{
+		Model:       p.Model,
+		Messages:    messages(req),
+		Temperature: p.Temperature,
+	}
+	if len(req.Schema) > 0 {
+		format := &openAIResponseFormat{Type: "json_schema"}
+		format.JSONSchema.Name = "reply"
+		format.JSONSchema.Schema = req.Schema
+		format.JSONSchema.Strict = p.Strict
+		body.ResponseFormat = format
+	}
+
+	var reply struct {
+		Choices []struct {
+			Message message `json:"message"`
+		} `json:"choices"`
+	}
+	if err := p.post(ctx, body, &reply); err != nil {
+		return "", err
+	}
+	if len(reply.Choices) == 0 || reply.Choices[0].Message.Content == "" {
+		return "", ErrEmptyReply
+	}
+	return reply.Choices[0].Message.Content, nil
+}
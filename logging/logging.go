@@ -0,0 +1,66 @@
+// Package logging builds the log/slog handler the commands log through:
+// text or JSON, at a level, with session values redacted whatever the call
+// site passes. Attributes are snake_case, e.g. session_id, list_id,
+// question_type and endpoint, so JSON logs can be searched by them.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+const (
+	FORMAT_TEXT = "text"
+	FORMAT_JSON = "json"
+)
+
+var FORMATS = []string{FORMAT_TEXT, FORMAT_JSON}
+
+// REDACTED replaces the value of every redacted attribute.
+const REDACTED = "REDACTED"
+
+// Attribute and group keys whose values are never written, compared without
+// regard to case.
+var redactedKeys = []string{
+	"cookie",
+	"cookies",
+	"set-cookie",
+	"secret",
+	"guid",
+	"jsessionid",
+	"awsalb",
+	"authorization",
+	"api_key",
+	"password",
+}
+
+// NewHandler writes records of level and above to w, as FORMAT_TEXT or
+// FORMAT_JSON.
+func NewHandler(w io.Writer, format string, level slog.Leveler) (slog.Handler, error) {
+	options := &slog.HandlerOptions{Level: level, ReplaceAttr: redact}
+	switch format {
+	case FORMAT_TEXT, "":
+		return slog.NewTextHandler(w, options), nil
+	case FORMAT_JSON:
+		return slog.NewJSONHandler(w, options), nil
+	}
+	return nil, fmt.Errorf("unknown log format %q", format)
+}
+
+// Redacts an attribute by its own key, or by the key of a group it is in.
+func redact(groups []string, a slog.Attr) slog.Attr {
+	if a.Value.Kind() == slog.KindGroup {
+		return a
+	}
+	if isRedacted(a.Key) || slices.ContainsFunc(groups, isRedacted) {
+		return slog.String(a.Key, REDACTED)
+	}
+	return a
+}
+
+func isRedacted(key string) bool {
+	return slices.Contains(redactedKeys, strings.ToLower(key))
+}
@@ -0,0 +1,63 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestRedaction(t *testing.T) {
+	var out bytes.Buffer
+	handler, err := NewHandler(&out, FORMAT_JSON, slog.LevelInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(handler).With("session_id", "a1b2", "Guid", "guid-value")
+
+	logger.Debug("hidden", "list_id", 1)
+	logger.Info("request",
+		"endpoint", "https://www.vocabulary.com/challenge/start.json",
+		"Cookie", "JSESSIONID=session-value;",
+		slog.Group("secret", "value", "secret-value"),
+		slog.Group("response", "status", 200, "secret", "next-secret"),
+	)
+
+	if strings.Contains(out.String(), "hidden") {
+		t.Error("debug record written at info level")
+	}
+	for _, value := range []string{"guid-value", "session-value", "secret-value", "next-secret"} {
+		if strings.Contains(out.String(), value) {
+			t.Errorf("log contains %q:\n%s", value, out.String())
+		}
+	}
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+		t.Fatalf("not one JSON record: %v\n%s", err, out.String())
+	}
+	if record["session_id"] != "a1b2" || record["Guid"] != REDACTED || record["Cookie"] != REDACTED {
+		t.Errorf("record = %v", record)
+	}
+	response := record["response"].(map[string]interface{})
+	if response["status"] != 200.0 || response["secret"] != REDACTED {
+		t.Errorf("response group = %v", response)
+	}
+}
+
+func TestFormats(t *testing.T) {
+	var out bytes.Buffer
+	handler, err := NewHandler(&out, FORMAT_TEXT, slog.LevelDebug)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slog.New(handler).Debug("fetching next question", "secret", "s")
+	if got := out.String(); !strings.Contains(got, `msg="fetching next question" secret=REDACTED`) {
+		t.Errorf("text record = %q", got)
+	}
+
+	if _, err := NewHandler(&out, "xml", slog.LevelInfo); err == nil {
+		t.Error("want an error for an unknown format")
+	}
+}
@@ -2,29 +2,208 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
 
-	"github.com/rodatboat/go-vocab/application"
+	"github.com/rodatboat/go-vocab/config"
+	"github.com/rodatboat/go-vocab/logging"
 )
 
+// Exit codes are stable so scripts can tell failures apart.
+const (
+	exitOK          = 0
+	exitFailure     = 1   // the command ran and failed
+	exitUsage       = 2   // bad arguments, flags or config
+	exitNotLoggedIn = 3   // vocabulary.com rejected the session cookies
+	exitInterrupted = 130 // stopped by SIGINT or SIGTERM, as shells report it
+)
+
+// interruptContext is cancelled by SIGINT or SIGTERM, so a command stops
+// between requests instead of being killed halfway.
+func interruptContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+// command is one `go-vocab <name>` subcommand. globals are the flags given
+// before the name, see commandFlags.
+type command struct {
+	name    string
+	summary string
+	run     func(globals, args []string) int
+}
+
+var commands = []command{
+	{"practice", "answer questions of a word list on vocabulary.com", runPractice},
+	{"study", "review stored words offline with spaced repetition", runStudy},
+	{"lists", "show your level and word lists", runLists},
+	{"stats", "count stored questions and answers", runStats},
+	{"inspect", "show a stored question or parse a saved response", runInspect},
+	{"export", "export stored questions, e.g. as an Anki deck", runExport},
+	{"report", "chart the progress of a word list", runReport},
+	{"journal", "rebuild the store from practice journals", runJournal},
+	{"migrate", "apply or roll back store migrations", runMigrate},
+	{"config", "show the effective config", runConfig},
+}
+
+const mainUsage = `usage: go-vocab [global flags] <command> [flags] [args]
+
+Global flags apply to every command:
+  -config FILE      config file (.yaml, .yml or .toml)
+  -dsn DSN          store DSN (postgres://, sqlite://, memory://)
+  -log-level LEVEL  debug, info, warn or error
+Any other config flag may be given here too, see go-vocab config show.
+
+Exit codes: 0 ok, 1 failure, 2 usage or config error, 3 not logged in,
+130 interrupted.
+Run go-vocab help <command> for the flags of a command.
+`
+
 func main() {
-	// args := os.Args
-	Ja3 := "123"
-	listId := 2444808
-	runner := application.New(application.RunParams{
-		ListId:     listId,
-		Ja3:        Ja3,
-		AlbCookie:  "123",
-		JSessionId: "123",
-		Guid:       "123",
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	flags := flag.NewFlagSet("go-vocab", flag.ContinueOnError)
+	flags.Usage = func() { printUsage(flags.Output()) }
+	config.RegisterFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return parseExitCode(err)
+	}
+	rest := flags.Args()
+	globals := args[:len(args)-len(rest)]
+	if len(globals) > 0 && globals[len(globals)-1] == "--" {
+		globals = globals[:len(globals)-1]
+	}
+	if len(rest) == 0 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	name := rest[0]
+	if name == "help" {
+		return runHelp(rest[1:])
+	}
+	cmd, ok := findCommand(name)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "go-vocab: unknown command %q\n", name)
+		flags.Usage()
+		return exitUsage
+	}
+	return cmd.run(globals, rest[1:])
+}
+
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprint(w, mainUsage)
+	fmt.Fprintln(w, "\nCommands:")
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, cmd := range commands {
+		fmt.Fprintf(table, "  %s\t%s\n", cmd.name, cmd.summary)
+	}
+	table.Flush()
+}
+
+func runHelp(args []string) int {
+	if len(args) == 0 {
+		printUsage(os.Stdout)
+		return exitOK
+	}
+	cmd, ok := findCommand(args[0])
+	if !ok {
+		fmt.Fprintf(os.Stderr, "go-vocab: unknown command %q\n", args[0])
+		return exitUsage
+	}
+	return cmd.run(nil, []string{"-h"})
+}
+
+// -h is a request, not a mistake.
+func parseExitCode(err error) int {
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	return exitUsage
+}
+
+func isHelpFlag(arg string) bool {
+	return arg == "-h" || arg == "-help" || arg == "--help"
+}
+
+// commandFlags is the flag set of one command, with every config flag
+// registered. The global flags are parsed ahead of the command's own
+// arguments, so a flag repeated after the command name wins.
+type commandFlags struct {
+	*flag.FlagSet
+	config  *config.Flags
+	globals []string
+}
+
+func newCommandFlags(name, usage string, globals []string) *commandFlags {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	f := &commandFlags{
+		FlagSet: flags,
+		config:  config.RegisterFlags(flags),
+		globals: globals,
+	}
+	configFlags := map[string]bool{}
+	flags.VisitAll(func(flag *flag.Flag) { configFlags[flag.Name] = true })
+	flags.Usage = func() { printCommandUsage(flags, usage, configFlags) }
+	return f
+}
+
+// The config flags would bury the command's own, so they are only pointed
+// to.
+func printCommandUsage(flags *flag.FlagSet, usage string, configFlags map[string]bool) {
+	own := flag.NewFlagSet(flags.Name(), flag.ContinueOnError)
+	own.SetOutput(flags.Output())
+	ownCount := 0
+	flags.VisitAll(func(f *flag.Flag) {
+		if configFlags[f.Name] {
+			return
+		}
+		own.Var(f.Value, f.Name, f.Usage)
+		own.Lookup(f.Name).DefValue = f.DefValue
+		ownCount++
 	})
 
-	isLoggedIn := runner.IsLoggedIn()
-	if !isLoggedIn {
-		fmt.Println("User not logged in, exiting...")
-		return
+	fmt.Fprint(flags.Output(), usage)
+	if ownCount > 0 {
+		fmt.Fprintln(flags.Output(), "\nFlags:")
+		own.PrintDefaults()
 	}
-	runner.Practice()
-	defer runner.Conn.Close(context.Background())
+	fmt.Fprintln(flags.Output(), "\nEvery config flag (-config, -dsn, -log-level, -list...) is accepted too,\nsee go-vocab config show.")
+}
+
+func (f *commandFlags) Parse(args []string) error {
+	return f.FlagSet.Parse(append(append([]string(nil), f.globals...), args...))
+}
 
+// Load builds the config and sets up logging with its log_level and
+// log_format.
+func (f *commandFlags) Load() (*config.Config, error) {
+	conf, err := f.config.Load()
+	if err != nil {
+		return nil, err
+	}
+	handler, err := logging.NewHandler(os.Stderr, conf.LogFormat, conf.SlogLevel())
+	if err != nil {
+		return nil, err
+	}
+	slog.SetDefault(slog.New(handler))
+	slog.Debug("config loaded", "command", f.Name())
+	return conf, nil
 }
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRunExitCodes(t *testing.T) {
+	t.Setenv("GOVOCAB_CONFIG", "")
+	dsn := "sqlite://" + filepath.Join(t.TempDir(), "vocab.db")
+
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"no command", nil, exitUsage},
+		{"unknown command", []string{"nope"}, exitUsage},
+		{"help", []string{"help"}, exitOK},
+		{"help command", []string{"help", "stats"}, exitOK},
+		{"help subcommand", []string{"help", "report"}, exitOK},
+		{"help unknown", []string{"help", "nope"}, exitUsage},
+		{"command -h", []string{"study", "-h"}, exitOK},
+		{"bad global flag", []string{"-nope", "stats"}, exitUsage},
+		{"bad command flag", []string{"stats", "-nope"}, exitUsage},
+		{"bad config", []string{"-log-level", "loud", "stats"}, exitUsage},
+		{"stats", []string{"-dsn", dsn, "stats"}, exitOK},
+		{"global flag overridden", []string{"-dsn", "nope", "stats", "-dsn", dsn}, exitOK},
+		{"practice without session", []string{"-dsn", dsn, "practice"}, exitUsage},
+		{"inspect missing question", []string{"-dsn", dsn, "inspect", "question", "1"}, exitFailure},
+		{"inspect bad id", []string{"-dsn", dsn, "inspect", "question", "one"}, exitUsage},
+		{"inspect response", []string{"inspect", "response", "example/example.start.json"}, exitOK},
+		{"inspect -h", []string{"inspect", "-h"}, exitOK},
+		{"inspect unknown", []string{"inspect", "nope", "1"}, exitUsage},
+		{"export empty store, flags after format", []string{"-dsn", dsn, "export", "anki", "-o", filepath.Join(t.TempDir(), "deck.apkg")}, exitFailure},
+		{"export without format", []string{"-dsn", dsn, "export"}, exitUsage},
+		{"journal without files", []string{"-dsn", dsn, "journal", "replay"}, exitUsage},
+		{"journal missing file", []string{"-dsn", dsn, "journal", "replay", "nope.jsonl"}, exitFailure},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := run(test.args); got != test.want {
+				t.Errorf("run(%q) = %d, want %d", test.args, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package media
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+)
+
+const indexFileName = "index.json"
+
+// Cache stores downloaded files under their sha256, so the same image
+// referenced from several questions is only kept once. An index maps each
+// source URL to its file so a URL is only downloaded once.
+type Cache struct {
+	Dir    string
+	Client *http.Client
+
+	mu    sync.Mutex
+	index map[string]string
+}
+
+func NewCache(dir string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating media cache: %w", err)
+	}
+	cache := &Cache{Dir: dir, Client: http.DefaultClient, index: map[string]string{}}
+
+	data, err := os.ReadFile(filepath.Join(dir, indexFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading media index: %w", err)
+	}
+	if err := json.Unmarshal(data, &cache.index); err != nil {
+		return nil, fmt.Errorf("reading media index: %w", err)
+	}
+	return cache, nil
+}
+
+// Fetch downloads sourceURL unless it is already cached and returns its path
+// relative to Dir, e.g. "ab/ab12...ef.jpg".
+func (c *Cache) Fetch(ctx context.Context, sourceURL string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if relPath, ok := c.index[sourceURL]; ok {
+		if _, err := os.Stat(filepath.Join(c.Dir, relPath)); err == nil {
+			return relPath, nil
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sourceURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("downloading %s: status %d", sourceURL, resp.StatusCode)
+	}
+
+	// Write to a temp file while hashing, then move it to its content address.
+	tmp, err := os.CreateTemp(c.Dir, "download-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(tmp, hash), resp.Body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("downloading %s: %w", sourceURL, err)
+	}
+
+	sum := hex.EncodeToString(hash.Sum(nil))
+	relPath := path.Join(sum[:2], sum+extension(sourceURL))
+	fullPath := filepath.Join(c.Dir, filepath.FromSlash(relPath))
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), fullPath); err != nil {
+		return "", err
+	}
+
+	c.index[sourceURL] = relPath
+	if err := c.saveIndex(); err != nil {
+		return "", err
+	}
+	return relPath, nil
+}
+
+// Path is the absolute location of a path returned by Fetch.
+func (c *Cache) Path(relPath string) string {
+	return filepath.Join(c.Dir, filepath.FromSlash(relPath))
+}
+
+func (c *Cache) saveIndex() error {
+	data, err := json.MarshalIndent(c.index, "", "  ")
+	if err != nil {
+		return err
+	}
+	indexPath := filepath.Join(c.Dir, indexFileName)
+	if err := os.WriteFile(indexPath+".tmp", data, 0o644); err != nil {
+		return fmt.Errorf("writing media index: %w", err)
+	}
+	return os.Rename(indexPath+".tmp", indexPath)
+}
+
+func extension(sourceURL string) string {
+	parsed, err := url.Parse(sourceURL)
+	if err != nil {
+		return ""
+	}
+	return path.Ext(parsed.Path)
+}
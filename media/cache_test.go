@@ -0,0 +1,71 @@
+package media
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestCacheFetch(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path == "/missing.jpg" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("same picture"))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	cache, err := NewCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := cache.Fetch(ctx, server.URL+"/questions/a.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// sha256("same picture")
+	if want := "97/975a0ac904e288b039c3eeb0429a66d88814f06a27b5291b5d1bd28d5adc2ece.jpg"; first != want {
+		t.Errorf("cached as %s, want %s", first, want)
+	}
+	data, err := os.ReadFile(cache.Path(first))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "same picture" {
+		t.Errorf("cached file holds %q", data)
+	}
+
+	// Same content from another URL lands in the same file.
+	second, err := cache.Fetch(ctx, server.URL+"/questions/b.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second != first {
+		t.Errorf("same content cached as %s and %s", first, second)
+	}
+
+	// A cached URL is not downloaded again, even by a new Cache on the same dir.
+	reopened, err := NewCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := reopened.Fetch(ctx, server.URL+"/questions/a.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != first || requests != 2 {
+		t.Errorf("got %s after %d requests, want %s after 2", again, requests, first)
+	}
+
+	if _, err := cache.Fetch(ctx, server.URL+"/missing.jpg"); err == nil {
+		t.Error("want error for missing image")
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/rodatboat/go-vocab/db"
+)
+
+const migrateUsage = `usage: go-vocab migrate [flags] up|down|status
+
+  up       apply all pending migrations
+  down     roll back the last applied migration (-steps N for more)
+  status   list migrations and whether they are applied
+`
+
+func runMigrate(globals, args []string) int {
+	flags := newCommandFlags("migrate", migrateUsage, globals)
+	steps := flags.Int("steps", 1, "number of migrations to roll back with down")
+	if err := flags.Parse(args); err != nil {
+		return parseExitCode(err)
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return exitUsage
+	}
+	conf, err := flags.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	ctx := context.Background()
+	store, err := db.OpenUnmigrated(ctx, conf.StoreDSN())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error opening store:", err)
+		return exitFailure
+	}
+	defer store.Close()
+
+	migrator, ok := store.(db.Migrator)
+	if !ok {
+		fmt.Fprintln(os.Stderr, "Error:", db.ErrNoMigrations)
+		return exitFailure
+	}
+
+	switch flags.Arg(0) {
+	case "up":
+		ran, err := migrator.MigrateUp(ctx)
+		for _, migration := range ran {
+			fmt.Printf("applied  %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error migrating up:", err)
+			return exitFailure
+		}
+		if len(ran) == 0 {
+			fmt.Println("Schema is up to date.")
+		}
+	case "down":
+		ran, err := migrator.MigrateDown(ctx, *steps)
+		for _, migration := range ran {
+			fmt.Printf("reverted %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error migrating down:", err)
+			return exitFailure
+		}
+	case "status":
+		statuses, err := migrator.MigrationStatus(ctx)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error reading migration status:", err)
+			return exitFailure
+		}
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.Applied {
+				appliedAt = status.AppliedAt.Local().Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-30s %s\n", status.Version, status.Name, appliedAt)
+		}
+	default:
+		flags.Usage()
+		return exitUsage
+	}
+	return exitOK
+}
@@ -0,0 +1,95 @@
+package model
+
+// ChallengeResponse is the envelope returned by start.json, nextquestion.json
+// and saveanswer.json.
+type ChallengeResponse struct {
+	V        int                `json:"v"`
+	Question *ChallengeQuestion `json:"question,omitempty"`
+	Round    *Round             `json:"round,omitempty"`
+	Action   string             `json:"action,omitempty"`
+	PData    *PlayerData        `json:"pdata,omitempty"`
+	Game     *Game              `json:"game,omitempty"`
+	Answer   *AnswerResult      `json:"answer,omitempty"`
+	Secret   string             `json:"secret"`
+
+	// Older start.json responses put the question at the top level, with
+	// `qtype` instead of `type` and `cmd` instead of `action`.
+	QType      string  `json:"qtype,omitempty"`
+	Cmd        string  `json:"cmd,omitempty"`
+	Code       string  `json:"code,omitempty"`
+	Difficulty float64 `json:"difficulty,omitempty"`
+
+	// Set on 400 responses, e.g. "RestartChallengeException".
+	Error string `json:"error,omitempty"`
+}
+
+type ChallengeQuestion struct {
+	Turn        int         `json:"turn"`
+	Type        string      `json:"type"`
+	Category    string      `json:"category"`
+	Difficulty  float64     `json:"difficulty"`
+	AnswerStats AnswerStats `json:"answerstats"`
+	Code        string      `json:"code"`
+}
+
+type AnswerStats struct {
+	Correct int `json:"correct"`
+	Total   int `json:"total"`
+}
+
+type Round struct {
+	Number      int `json:"number"`
+	Streak      int `json:"streak"`
+	PlayedCount int `json:"played_count"`
+}
+
+type PlayerData struct {
+	Points      int          `json:"points"`
+	Level       Level        `json:"level"`
+	NumPlayed   int          `json:"numplayed"`
+	NumMastered int          `json:"nummastered"`
+	Lists       []PlayerList `json:"lists"`
+}
+
+type Level struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Milestone int    `json:"milestone"`
+	Progress  int    `json:"progress"`
+}
+
+type PlayerList struct {
+	ListId    int     `json:"listId"`
+	Name      string  `json:"name"`
+	WordCount int     `json:"wordcount"`
+	Progress  float64 `json:"progress"`
+	Priority  int     `json:"priority"`
+	Current   bool    `json:"current"`
+}
+
+type Game struct {
+	WordListId int     `json:"wordlistid"`
+	Name       string  `json:"name"`
+	Progress   float64 `json:"progress"`
+	Type       string  `json:"type"`
+	ActivityId string  `json:"activityid"`
+	Played     int     `json:"played"`
+	Correct    int     `json:"correct"`
+	Points     int     `json:"points"`
+}
+
+// AnswerResult is the `answer` object of a saveanswer.json response.
+type AnswerResult struct {
+	Correct bool   `json:"correct"`
+	Word    string `json:"word"`
+	Points  int    `json:"points"`
+	Bonus   int    `json:"bonus"`
+}
+
+// MeResponse is the part of auth/me.json used to check the session.
+type MeResponse struct {
+	Auth struct {
+		LoggedIn bool   `json:"loggedin"`
+		Nickname string `json:"nickname"`
+	} `json:"auth"`
+}
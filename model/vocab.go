@@ -1,6 +1,9 @@
 package model
 
+import "time"
+
 type Question struct {
+	ID           int
 	QuestionType string
 	DecodedCode  string
 	Code         string
@@ -14,11 +17,110 @@ type Question struct {
 
 	IsCorrect  bool
 	TargetWord string
+	WordID     int
+
+	// Slide metadata. Mode is the class of the mode span: mastery, review,
+	// progress, interrogate or assessment.
+	Mode        string
+	PointValue  int
+	SourceURL   string
+	SourceTitle string
+	SlideType   string
+	Template    string
+	AudioID     string
+
+	// From the API envelope. AnswerStats counts every player's answers, so
+	// CorrectRate (correct/total) is a global difficulty signal independent
+	// of Difficulty. It is zero when nobody has answered yet.
+	Turn        int
+	Category    string
+	AnswerStats AnswerStats
+	CorrectRate float64
+}
+
+// Word is a target word shared by every question that asks about it.
+type Word struct {
+	ID           int
+	Lemma        string
+	FirstSeen    time.Time
+	LastSeen     time.Time
+	TimesAsked   int
+	TimesCorrect int
+	// Estimated chance of answering correctly, (correct + 1) / (asked + 2).
+	Mastery float64
+}
+
+// Attempt is a single answer submitted for a question.
+type Attempt struct {
+	ID           int
+	QuestionID   int
+	ChoiceKey    string
+	ChoiceValue  string
+	IsCorrect    bool
+	Points       int
+	Bonus        int
+	ResponseTime int // milliseconds, as sent in AnswerReq.Rt
+	SessionId    string
+	CreatedAt    time.Time
+}
+
+// Review is the spaced-repetition state of a word in study mode.
+type Review struct {
+	WordID         int
+	Ease           float64
+	Interval       int // days
+	Repetitions    int
+	DueAt          time.Time
+	LastReviewedAt time.Time
+}
+
+// Explanation is the LLM's account of why a question's answer is right,
+// generated once the answer is known.
+type Explanation struct {
+	QuestionID  int
+	Explanation string
+	// One line, for the target word.
+	Definition string
+	Mnemonic   string
+	Model      string
+	CreatedAt  time.Time
+}
+
+// Checkpoint is where the last practice session on a list stopped, so the
+// next one can resume the round instead of starting over.
+type Checkpoint struct {
+	ListId    int
+	SessionId string
+	// Empty when the round was over.
+	Secret   string
+	Progress float64
+	// The question left open, 0 when there was none.
+	QuestionID int
+	SavedAt    time.Time
 }
 
 type QuestionChoices struct {
 	Key   string `json:"key"`
 	Value string `json:"value"`
+	// Image choices (I-type) have no text, only a picture.
+	ImageURL string `json:"imageUrl,omitempty"`
+	// Path of the downloaded image, relative to the media cache directory.
+	MediaPath string `json:"mediaPath,omitempty"`
+}
+
+type QuestionStats struct {
+	Total    int
+	Answered int
+	Correct  int
+	ByType   map[string]QuestionTypeStats
+
+	Attempts        int
+	CorrectAttempts int
+}
+
+type QuestionTypeStats struct {
+	Total   int
+	Correct int
 }
 
 type WordList struct {
@@ -27,25 +129,66 @@ type WordList struct {
 	WordCount            int
 	CompletionPercentage float64
 	Completed            bool
+	Priority             int
+	Current              bool
+}
+
+// AccountSnapshot is the user's level and lists as reported by the pdata of
+// one response.
+type AccountSnapshot struct {
+	ID          int
+	Points      int
+	Level       Level
+	NumPlayed   int
+	NumMastered int
+	Lists       []WordList
+	TakenAt     time.Time
+}
+
+// ProgressSnapshot is the game (and, when present, pdata) state of one
+// response, kept to chart progress on a list over time.
+type ProgressSnapshot struct {
+	ID       int
+	ListId   int
+	ListName string
+	// Completion of the list, 0 to 1.
+	Progress    float64
+	Played      int
+	Correct     int
+	Points      int
+	NumPlayed   int
+	NumMastered int
+	SessionId   string
+	TakenAt     time.Time
+}
+
+// Accuracy is the share of questions answered correctly in the game, 0 to 1.
+func (s ProgressSnapshot) Accuracy() float64 {
+	if s.Played == 0 {
+		return 0
+	}
+	return float64(s.Correct) / float64(s.Played)
 }
 
+// The challenge requests are sent form encoded, with the keys of the form
+// tags.
 type AnswerReq struct {
-	Secret string `json:"secret,omitempty"`
-	V      int    `json:"v"`
-	Rt     int    `json:"rt"`
-	A      string `json:"a"`
+	Secret string `json:"secret,omitempty" form:"secret,omitempty"`
+	V      int    `json:"v" form:"v"`
+	Rt     int    `json:"rt" form:"rt"`
+	A      string `json:"a" form:"a"`
 }
 
 type NextQuestionReq struct {
-	Secret string `json:"secret,omitempty"`
-	V      int    `json:"v"`
+	Secret string `json:"secret,omitempty" form:"secret,omitempty"`
+	V      int    `json:"v" form:"v"`
 }
 
 type StartPracticeReq struct {
-	V            int    `json:"v"`
-	ActivityType string `json:"activitytype"`
-	WordListId   int    `json:"wordlistid"`
-	Secret       string `json:"secret,omitempty"`
+	V            int    `json:"v" form:"v"`
+	ActivityType string `json:"activitytype" form:"activitytype"`
+	WordListId   int    `json:"wordlistid" form:"wordlistid,omitempty"`
+	Secret       string `json:"secret,omitempty" form:"secret,omitempty"`
 }
 
 type Cookies struct {
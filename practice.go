@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/rodatboat/go-vocab/application"
+)
+
+const practiceUsage = `usage: go-vocab practice [flags]
+
+Answers the questions of a word list on vocabulary.com until the list is
+done, asking the LLM when a question has not been seen before. Needs
+list_id, ja3 and the session cookies.
+
+Ctrl-C (or SIGTERM) stops after the current request and checkpoints the
+round in the store; the next practice on the list resumes it.
+`
+
+func runPractice(globals, args []string) int {
+	flags := newCommandFlags("practice", practiceUsage, globals)
+	if err := flags.Parse(args); err != nil {
+		return parseExitCode(err)
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return exitUsage
+	}
+	conf, err := flags.Load()
+	if err == nil {
+		err = conf.ValidateSession()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	runner, err := application.New(conf.RunParams())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error creating runner:", err)
+		return exitFailure
+	}
+	defer runner.Close()
+
+	ctx, stop := interruptContext()
+	defer stop()
+
+	err = runner.Practice(ctx)
+	if ctx.Err() != nil {
+		fmt.Fprintln(os.Stderr, "Interrupted, exiting...")
+		return exitInterrupted
+	}
+	if errors.Is(err, application.ErrNotLoggedIn) {
+		fmt.Fprintln(os.Stderr, "User not logged in, exiting...")
+		return exitNotLoggedIn
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error practicing:", err)
+		return exitFailure
+	}
+	return exitOK
+}
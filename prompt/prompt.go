@@ -0,0 +1,169 @@
+// Package prompt renders the prompts sent to the LLM from text/template
+// files. Each prompt has a system and a user template, e.g.
+// answer.system.tmpl and answer.user.tmpl. The built-in templates can be
+// overridden from a directory, for every question type at its top level or
+// for one type in a subdirectory named after it:
+//
+//	prompts/answer.system.tmpl     all question types
+//	prompts/S/answer.user.tmpl     S-type questions only
+package prompt
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/rodatboat/go-vocab/model"
+)
+
+//go:embed templates
+var builtin embed.FS
+
+const templateExt = ".tmpl"
+
+// Data is what a template is executed with.
+type Data struct {
+	QuestionType string
+	Context      string
+	Question     string
+	Choices      []model.QuestionChoices
+	TargetWord   string
+	// Empty until the question has been answered.
+	Answer    string
+	AnswerKey string
+}
+
+func DataFor(question model.Question) Data {
+	return Data{
+		QuestionType: question.QuestionType,
+		Context:      question.QuestionContext,
+		Question:     question.Question,
+		Choices:      question.Choices,
+		TargetWord:   question.TargetWord,
+		Answer:       question.Answer,
+		AnswerKey:    question.AnswerKey,
+	}
+}
+
+// Templates are the built-in templates with any overrides applied.
+type Templates struct {
+	// Keyed by "answer.user", or "S/answer.user" for a type override.
+	byName map[string]*template.Template
+}
+
+var funcs = template.FuncMap{
+	// json quotes a value for use inside a JSON document.
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// Load parses the built-in templates and the overrides in dir, if any. An
+// override for a template that does not exist is an error, so a misspelt
+// file name is not silently ignored.
+func Load(dir string) (*Templates, error) {
+	t := &Templates{byName: map[string]*template.Template{}}
+
+	entries, err := fs.ReadDir(builtin, "templates")
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), templateExt) {
+			continue
+		}
+		text, err := fs.ReadFile(builtin, path.Join("templates", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if err := t.add(strings.TrimSuffix(entry.Name(), templateExt), string(text)); err != nil {
+			return nil, err
+		}
+	}
+
+	if dir == "" {
+		return t, nil
+	}
+	matches, err := filepath.Glob(filepath.Join(dir, "*"+templateExt))
+	if err != nil {
+		return nil, err
+	}
+	typeMatches, err := filepath.Glob(filepath.Join(dir, "*", "*"+templateExt))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range append(matches, typeMatches...) {
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return nil, err
+		}
+		name := strings.TrimSuffix(filepath.ToSlash(rel), templateExt)
+		if _, ok := t.byName[path.Base(name)]; !ok {
+			return nil, fmt.Errorf("prompt override %s: no built-in template %s%s", file, path.Base(name), templateExt)
+		}
+		text, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("reading prompt override: %w", err)
+		}
+		if err := t.add(name, string(text)); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+func (t *Templates) add(name, text string) error {
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return fmt.Errorf("parsing prompt %s: %w", name, err)
+	}
+	t.byName[name] = tmpl
+	return nil
+}
+
+// Render executes the system and user templates of the named prompt, using
+// the overrides for data.QuestionType where there are any.
+func (t *Templates) Render(name string, data Data) (system, user string, err error) {
+	system, err = t.execute(name+".system", data)
+	if err != nil {
+		return "", "", err
+	}
+	user, err = t.execute(name+".user", data)
+	if err != nil {
+		return "", "", err
+	}
+	return system, user, nil
+}
+
+func (t *Templates) execute(name string, data Data) (string, error) {
+	tmpl, ok := t.byName[data.QuestionType+"/"+name]
+	if !ok {
+		tmpl, ok = t.byName[name]
+	}
+	if !ok {
+		return "", fmt.Errorf("no prompt template %s%s", name, templateExt)
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("rendering prompt %s: %w", name, err)
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+// Schema is the built-in JSON schema of the named prompt's reply.
+func Schema(name string) (json.RawMessage, error) {
+	data, err := fs.ReadFile(builtin, path.Join("templates", name+".schema.json"))
+	if err != nil {
+		return nil, fmt.Errorf("no schema for prompt %s: %w", name, err)
+	}
+	return data, nil
+}
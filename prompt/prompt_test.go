@@ -0,0 +1,170 @@
+package prompt
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rodatboat/go-vocab/model"
+)
+
+func TestRenderEscapesQuestionText(t *testing.T) {
+	templates, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	question := model.Question{
+		QuestionType:    "S",
+		QuestionContext: "He said \"100% sure\",\nthen left C:\\temp.",
+		Question:        "%s means %d",
+		Choices: []model.QuestionChoices{
+			{Key: "a1", Value: "certain \"ish\""},
+			{Key: "b2", Value: "doubtful"},
+		},
+	}
+
+	system, user, err := templates.Render("answer", DataFor(question))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(system, "vocabulary teacher") {
+		t.Errorf("system prompt = %q", system)
+	}
+
+	var got struct {
+		Context  string                  `json:"context"`
+		Question string                  `json:"question"`
+		Choices  []model.QuestionChoices `json:"choices"`
+	}
+	if err := json.Unmarshal([]byte(user), &got); err != nil {
+		t.Fatalf("user prompt is not JSON: %v\n%s", err, user)
+	}
+	if got.Context != question.QuestionContext || got.Question != question.Question || got.Choices[0] != question.Choices[0] {
+		t.Errorf("user prompt lost text: %+v", got)
+	}
+
+	for _, name := range []string{"answer", "explain"} {
+		schema, err := Schema(name)
+		if err != nil || !json.Valid(schema) {
+			t.Errorf("%s schema: %v", name, err)
+		}
+	}
+}
+
+// OpenAI's strict mode wants every object closed, with all its properties
+// required.
+func TestSchemasAreStrict(t *testing.T) {
+	var check func(name, path string, schema map[string]interface{})
+	check = func(name, path string, schema map[string]interface{}) {
+		if schema["type"] != "object" {
+			return
+		}
+		if schema["additionalProperties"] != false {
+			t.Errorf("%s schema %s allows additional properties", name, path)
+		}
+		required, _ := schema["required"].([]interface{})
+		properties, _ := schema["properties"].(map[string]interface{})
+		if len(required) != len(properties) {
+			t.Errorf("%s schema %s requires %v of %d properties", name, path, required, len(properties))
+		}
+		for key, property := range properties {
+			check(name, path+"."+key, property.(map[string]interface{}))
+		}
+	}
+
+	for _, name := range []string{"answer", "explain"} {
+		data, err := Schema(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var schema map[string]interface{}
+		if err := json.Unmarshal(data, &schema); err != nil {
+			t.Fatal(err)
+		}
+		check(name, "$", schema)
+	}
+}
+
+func TestRenderExplain(t *testing.T) {
+	templates, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	question := model.Question{
+		QuestionType: "S",
+		Question:     "endowment means:",
+		Choices:      []model.QuestionChoices{{Key: "a1", Value: "talent"}, {Key: "b2", Value: "debt"}},
+		Answer:       "talent",
+		AnswerKey:    "a1",
+		TargetWord:   "endowment",
+	}
+	system, user, err := templates.Render("explain", DataFor(question))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(system, `"endowment"`) || !strings.Contains(system, "other choices") {
+		t.Errorf("system prompt = %q", system)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal([]byte(user), &got); err != nil || got["answer"] != "talent" || got["word"] != "endowment" {
+		t.Errorf("user prompt = %q (%v)", user, err)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOverrides(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "answer.system.tmpl"), "Answer a {{.QuestionType}}-type question.")
+	writeFile(t, filepath.Join(dir, "S", "answer.user.tmpl"), "Which choice means {{.TargetWord}}?{{range .Choices}} {{.Key}}={{.Value}}{{end}}")
+
+	templates, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	choices := []model.QuestionChoices{{Key: "a1", Value: "calm"}}
+
+	system, user, err := templates.Render("answer", Data{QuestionType: "S", TargetWord: "placid", Choices: choices})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if system != "Answer a S-type question." || user != "Which choice means placid? a1=calm" {
+		t.Errorf("S-type prompts = %q, %q", system, user)
+	}
+
+	system, user, err = templates.Render("answer", Data{QuestionType: "A", Choices: choices})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if system != "Answer a A-type question." || !strings.HasPrefix(user, `{"context": ""`) {
+		t.Errorf("A-type prompts = %q, %q", system, user)
+	}
+}
+
+func TestOverrideErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "S", "answr.user.tmpl"), "typo")
+	if _, err := Load(dir); err == nil || !strings.Contains(err.Error(), "answr.user") {
+		t.Errorf("want error naming the unknown template, got %v", err)
+	}
+
+	dir = t.TempDir()
+	writeFile(t, filepath.Join(dir, "answer.user.tmpl"), "{{.Contxt}}")
+	templates, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := templates.Render("answer", Data{}); err == nil {
+		t.Error("want an error for a field Data does not have")
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/rodatboat/go-vocab/db"
+	"github.com/rodatboat/go-vocab/report"
+)
+
+const reportUsage = `usage: go-vocab report progress -list ID [flags]
+
+  progress   chart completion, accuracy and mastered words of a list over time
+`
+
+func runReport(globals, args []string) int {
+	// report has a single subcommand, so its help is the help of progress.
+	if len(args) > 0 && isHelpFlag(args[0]) {
+		args = append([]string{"progress"}, args...)
+	}
+	if len(args) == 0 || args[0] != "progress" {
+		fmt.Fprint(os.Stderr, reportUsage)
+		return exitUsage
+	}
+
+	flags := newCommandFlags("report progress", reportUsage, globals)
+	asCsv := flags.Bool("csv", false, "print the snapshots as CSV instead of charts")
+	width := flags.Int("width", 60, "maximum chart width in columns")
+	height := flags.Int("height", 10, "chart height in rows")
+	if err := flags.Parse(args[1:]); err != nil {
+		return parseExitCode(err)
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return exitUsage
+	}
+	conf, err := flags.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	// -list is the list_id config setting, so it can also come from the file.
+	if conf.ListId == 0 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	ctx := context.Background()
+	store, err := db.Open(ctx, conf.StoreDSN())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error opening store:", err)
+		return exitFailure
+	}
+	defer store.Close()
+
+	snapshots, err := store.ListProgressSnapshots(ctx, db.ProgressFilter{ListId: conf.ListId})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error listing progress snapshots:", err)
+		return exitFailure
+	}
+
+	if *asCsv {
+		if err := report.ProgressCSV(os.Stdout, snapshots); err != nil {
+			fmt.Fprintln(os.Stderr, "Error writing CSV:", err)
+			return exitFailure
+		}
+		return exitOK
+	}
+	report.Progress(os.Stdout, snapshots, *width, *height)
+	return exitOK
+}
@@ -0,0 +1,82 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// Chart draws values left to right as a plot of height rows. Series longer
+// than width are sampled down to width columns. The y axis is labelled with
+// the minimum and maximum of the values.
+func Chart(w io.Writer, title string, values []float64, width, height int) {
+	fmt.Fprintln(w, title)
+	if len(values) == 0 {
+		fmt.Fprintln(w, "  (no data)")
+		return
+	}
+	if height < 2 {
+		height = 2
+	}
+	values = sample(values, width)
+
+	low, high := values[0], values[0]
+	for _, value := range values {
+		low = math.Min(low, value)
+		high = math.Max(high, value)
+	}
+	span := high - low
+	if span == 0 {
+		span = 1
+	}
+
+	levels := make([]int, len(values))
+	for i, value := range values {
+		levels[i] = int(math.Round((value - low) / span * float64(height-1)))
+	}
+
+	const labelWidth = 10
+	for row := height - 1; row >= 0; row-- {
+		label := ""
+		switch row {
+		case height - 1:
+			label = formatValue(high)
+		case 0:
+			label = formatValue(low)
+		}
+
+		var line strings.Builder
+		for _, level := range levels {
+			if level == row {
+				line.WriteByte('*')
+			} else {
+				line.WriteByte(' ')
+			}
+		}
+		fmt.Fprintf(w, "%*s |%s\n", labelWidth, label, strings.TrimRight(line.String(), " "))
+	}
+	fmt.Fprintf(w, "%*s +%s\n", labelWidth, "", strings.Repeat("-", len(levels)))
+}
+
+func formatValue(value float64) string {
+	if value == math.Trunc(value) {
+		return fmt.Sprintf("%.0f", value)
+	}
+	return fmt.Sprintf("%.1f", value)
+}
+
+// Picks evenly spaced values, always keeping the last one.
+func sample(values []float64, width int) []float64 {
+	if width <= 0 || len(values) <= width {
+		return values
+	}
+	if width == 1 {
+		return values[len(values)-1:]
+	}
+	sampled := make([]float64, width)
+	for i := range sampled {
+		sampled[i] = values[i*(len(values)-1)/(width-1)]
+	}
+	return sampled
+}
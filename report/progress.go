@@ -0,0 +1,76 @@
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/rodatboat/go-vocab/model"
+)
+
+const timeLayout = "2006-01-02 15:04"
+
+// Progress charts list completion, accuracy and mastered words over time.
+func Progress(w io.Writer, snapshots []model.ProgressSnapshot, width, height int) {
+	if len(snapshots) == 0 {
+		fmt.Fprintln(w, "No progress snapshots recorded yet.")
+		return
+	}
+
+	completion := make([]float64, len(snapshots))
+	accuracy := make([]float64, len(snapshots))
+	mastered := make([]float64, len(snapshots))
+	for i, snapshot := range snapshots {
+		completion[i] = snapshot.Progress * 100
+		accuracy[i] = snapshot.Accuracy() * 100
+		mastered[i] = float64(snapshot.NumMastered)
+	}
+
+	first, last := snapshots[0], snapshots[len(snapshots)-1]
+	fmt.Fprintf(w, "%s (%d), %d snapshots from %s to %s\n\n",
+		last.ListName, last.ListId, len(snapshots),
+		first.TakenAt.Local().Format(timeLayout), last.TakenAt.Local().Format(timeLayout))
+
+	Chart(w, "List completion (%)", completion, width, height)
+	fmt.Fprintln(w)
+	Chart(w, "Accuracy (%)", accuracy, width, height)
+	fmt.Fprintln(w)
+	Chart(w, "Words mastered", mastered, width, height)
+}
+
+// ProgressCSV writes one row per snapshot, with a header row.
+func ProgressCSV(w io.Writer, snapshots []model.ProgressSnapshot) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{
+		"taken_at",
+		"list_id",
+		"list_name",
+		"progress",
+		"played",
+		"correct",
+		"accuracy",
+		"points",
+		"num_played",
+		"num_mastered",
+		"session_id",
+	})
+	for _, snapshot := range snapshots {
+		out.Write([]string{
+			snapshot.TakenAt.UTC().Format(time.RFC3339),
+			strconv.Itoa(snapshot.ListId),
+			snapshot.ListName,
+			strconv.FormatFloat(snapshot.Progress, 'f', -1, 64),
+			strconv.Itoa(snapshot.Played),
+			strconv.Itoa(snapshot.Correct),
+			strconv.FormatFloat(snapshot.Accuracy(), 'f', 4, 64),
+			strconv.Itoa(snapshot.Points),
+			strconv.Itoa(snapshot.NumPlayed),
+			strconv.Itoa(snapshot.NumMastered),
+			snapshot.SessionId,
+		})
+	}
+	out.Flush()
+	return out.Error()
+}
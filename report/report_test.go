@@ -0,0 +1,70 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rodatboat/go-vocab/model"
+)
+
+func TestChart(t *testing.T) {
+	var out bytes.Buffer
+	Chart(&out, "Rising", []float64{0, 50, 100}, 40, 3)
+
+	want := strings.Join([]string{
+		"Rising",
+		"       100 |  *",
+		"           | *",
+		"         0 |*",
+		"           +---",
+		"",
+	}, "\n")
+	if out.String() != want {
+		t.Errorf("chart =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestChartSamplesToWidth(t *testing.T) {
+	values := make([]float64, 100)
+	for i := range values {
+		values[i] = float64(i)
+	}
+	var out bytes.Buffer
+	Chart(&out, "Long", values, 10, 4)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	axis := lines[len(lines)-1]
+	if !strings.HasSuffix(axis, "+"+strings.Repeat("-", 10)) {
+		t.Errorf("want 10 columns, got axis %q", axis)
+	}
+	if !strings.Contains(lines[1], "99 |") {
+		t.Errorf("last value not kept, top row %q", lines[1])
+	}
+}
+
+func TestProgressCSV(t *testing.T) {
+	var out bytes.Buffer
+	err := ProgressCSV(&out, []model.ProgressSnapshot{{
+		ListId:      2444808,
+		ListName:    "master, v2",
+		Progress:    0.9974227,
+		Played:      1643,
+		Correct:     1139,
+		Points:      99635,
+		NumPlayed:   66949,
+		NumMastered: 4853,
+		SessionId:   "abc",
+		TakenAt:     time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "taken_at,list_id,list_name,progress,played,correct,accuracy,points,num_played,num_mastered,session_id\n" +
+		"2024-03-01T09:00:00Z,2444808,\"master, v2\",0.9974227,1643,1139,0.6932,99635,66949,4853,abc\n"
+	if out.String() != want {
+		t.Errorf("csv =\n%s\nwant\n%s", out.String(), want)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/rodatboat/go-vocab/db"
+	"github.com/rodatboat/go-vocab/model"
+)
+
+const statsUsage = `usage: go-vocab stats [flags]
+
+Counts the questions in the store, in total and per question type, and the
+answers given to them.
+`
+
+func runStats(globals, args []string) int {
+	flags := newCommandFlags("stats", statsUsage, globals)
+	asJson := flags.Bool("json", false, "print the stats as JSON")
+	if err := flags.Parse(args); err != nil {
+		return parseExitCode(err)
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return exitUsage
+	}
+	conf, err := flags.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	ctx := context.Background()
+	store, err := db.Open(ctx, conf.StoreDSN())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error opening store:", err)
+		return exitFailure
+	}
+	defer store.Close()
+
+	stats, err := store.Stats(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error reading stats:", err)
+		return exitFailure
+	}
+
+	if *asJson {
+		if err := printJson(os.Stdout, stats); err != nil {
+			fmt.Fprintln(os.Stderr, "Error encoding stats:", err)
+			return exitFailure
+		}
+		return exitOK
+	}
+	printStats(os.Stdout, stats)
+	return exitOK
+}
+
+func printStats(w io.Writer, stats *model.QuestionStats) {
+	fmt.Fprintf(w, "Questions: %d (%d answered, %d correct)\n", stats.Total, stats.Answered, stats.Correct)
+	fmt.Fprintf(w, "Attempts:  %d (%d correct, %s)\n\n", stats.Attempts, stats.CorrectAttempts, percent(stats.CorrectAttempts, stats.Attempts))
+
+	types := make([]string, 0, len(stats.ByType))
+	for questionType := range stats.ByType {
+		types = append(types, questionType)
+	}
+	sort.Strings(types)
+
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "TYPE\tQUESTIONS\tCORRECT")
+	for _, questionType := range types {
+		typeStats := stats.ByType[questionType]
+		fmt.Fprintf(table, "%s\t%d\t%d\n", questionType, typeStats.Total, typeStats.Correct)
+	}
+	table.Flush()
+}
+
+func percent(part, total int) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f%%", float64(part)/float64(total)*100)
+}
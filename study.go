@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/rodatboat/go-vocab/db"
+	"github.com/rodatboat/go-vocab/study"
+)
+
+const studyUsage = `usage: go-vocab study [flags]
+
+Quizzes you on the words already in the store, scheduling each one with
+SM-2. Works offline; nothing is sent to vocabulary.com.
+`
+
+func runStudy(globals, args []string) int {
+	flags := newCommandFlags("study", studyUsage, globals)
+	limit := flags.Int("limit", 20, "maximum words per session, 0 for all due")
+	if err := flags.Parse(args); err != nil {
+		return parseExitCode(err)
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return exitUsage
+	}
+	conf, err := flags.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	ctx := context.Background()
+	store, err := db.Open(ctx, conf.StoreDSN())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error opening store:", err)
+		return exitFailure
+	}
+	defer store.Close()
+
+	session := study.Session{
+		Store: store,
+		In:    os.Stdin,
+		Out:   os.Stdout,
+		Limit: *limit,
+	}
+	if _, err := session.Run(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, "Error studying:", err)
+		return exitFailure
+	}
+	return exitOK
+}
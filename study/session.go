@@ -0,0 +1,267 @@
+package study
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rodatboat/go-vocab/db"
+	"github.com/rodatboat/go-vocab/model"
+)
+
+// Card is one word to review, quizzed through one of its stored questions.
+type Card struct {
+	Word     model.Word
+	Question model.Question
+	Review   model.Review
+	IsNew    bool
+	// Shown after the answer, nil when the question was never explained.
+	Explanation *model.Explanation
+}
+
+type Summary struct {
+	Reviewed int
+	Correct  int
+}
+
+// Session quizzes a human over In/Out using only what is already in Store.
+type Session struct {
+	Store db.QuestionStore
+	In    io.Reader
+	Out   io.Writer
+	// Maximum cards per session, zero means no limit.
+	Limit int
+	Now   func() time.Time
+}
+
+// Only questions whose answer is known can be graded. Spelling answers come
+// straight from the slide, the rest need a correct attempt. Image questions
+// have nothing to show in a terminal.
+func hasKnownAnswer(question model.Question) bool {
+	if question.QuestionType == "T" {
+		return question.Answer != ""
+	}
+	if !question.IsCorrect {
+		return false
+	}
+	for _, choice := range question.Choices {
+		if choice.Value == "" {
+			return false
+		}
+	}
+	_, ok := correctChoice(question)
+	return ok
+}
+
+// The choice with the answer key, or failing that with the answer's text,
+// for choices saved in another order or with other keys.
+func correctChoice(question model.Question) (model.QuestionChoices, bool) {
+	for _, choice := range question.Choices {
+		if question.AnswerKey != "" && choice.Key == question.AnswerKey {
+			return choice, true
+		}
+	}
+	for _, choice := range question.Choices {
+		if question.Answer != "" && strings.EqualFold(choice.Value, question.Answer) {
+			return choice, true
+		}
+	}
+	return model.QuestionChoices{}, false
+}
+
+// DueCards returns the words due for review, oldest due first, followed by
+// words that were never studied, the ones most players get wrong first.
+func DueCards(ctx context.Context, store db.QuestionStore, now time.Time, limit int) ([]Card, error) {
+	words, err := store.ListWords(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reviews, err := store.ListReviews(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reviewByWord := map[int]model.Review{}
+	for _, review := range reviews {
+		reviewByWord[review.WordID] = review
+	}
+	explanations, err := store.ListExplanations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	explanationByQuestion := map[int]model.Explanation{}
+	for _, explanation := range explanations {
+		explanationByQuestion[explanation.QuestionID] = explanation
+	}
+
+	var cards []Card
+	for _, word := range words {
+		review, ok := reviewByWord[word.ID]
+		if ok && review.DueAt.After(now) {
+			continue
+		}
+		if !ok {
+			review = NewReview(word.ID, now)
+		}
+
+		questions, err := store.ListQuestions(ctx, db.QuestionFilter{WordID: word.ID})
+		if err != nil {
+			return nil, err
+		}
+		var known []model.Question
+		for _, question := range questions {
+			if hasKnownAnswer(question) {
+				known = append(known, question)
+			}
+		}
+		if len(known) == 0 {
+			continue
+		}
+
+		card := Card{
+			Word: word,
+			// Rotate through the word's questions as it gets reviewed.
+			Question: known[review.Repetitions%len(known)],
+			Review:   review,
+			IsNew:    !ok,
+		}
+		if explanation, ok := explanationByQuestion[card.Question.ID]; ok {
+			card.Explanation = &explanation
+		}
+		cards = append(cards, card)
+	}
+
+	sort.SliceStable(cards, func(i, j int) bool {
+		if cards[i].IsNew != cards[j].IsNew {
+			return !cards[i].IsNew
+		}
+		if cards[i].IsNew {
+			return globalCorrectRate(cards[i].Question) < globalCorrectRate(cards[j].Question)
+		}
+		return cards[i].Review.DueAt.Before(cards[j].Review.DueAt)
+	})
+	if limit > 0 && len(cards) > limit {
+		cards = cards[:limit]
+	}
+	return cards, nil
+}
+
+// Questions nobody has answered yet count as easy.
+func globalCorrectRate(question model.Question) float64 {
+	if question.AnswerStats.Total == 0 {
+		return 1
+	}
+	return question.CorrectRate
+}
+
+// Run quizzes every due card until they run out, the input ends, or the
+// user types "q". Each answer is graded and its review saved right away.
+func (s *Session) Run(ctx context.Context) (Summary, error) {
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+
+	var summary Summary
+	cards, err := DueCards(ctx, s.Store, now(), s.Limit)
+	if err != nil {
+		return summary, err
+	}
+	if len(cards) == 0 {
+		fmt.Fprintln(s.Out, "Nothing to review right now.")
+		return summary, nil
+	}
+	fmt.Fprintf(s.Out, "%d words to review. Type q to stop.\n", len(cards))
+
+	input := bufio.NewScanner(s.In)
+	for i, card := range cards {
+		fmt.Fprintf(s.Out, "\n(%d/%d) ", i+1, len(cards))
+		s.show(card.Question)
+
+		fmt.Fprint(s.Out, "> ")
+		if !input.Scan() {
+			break
+		}
+		reply := strings.TrimSpace(input.Text())
+		if strings.EqualFold(reply, "q") {
+			break
+		}
+
+		correct := s.grade(card.Question, reply)
+		quality := QualityWrong
+		if correct {
+			quality = QualityCorrect
+			summary.Correct++
+			fmt.Fprintln(s.Out, "Correct!")
+		} else {
+			fmt.Fprintln(s.Out, "Wrong, the answer is:", card.Question.Answer)
+		}
+		s.explain(card.Explanation)
+
+		review := Schedule(card.Review, quality, now())
+		if err := s.Store.SaveReview(ctx, review); err != nil {
+			return summary, err
+		}
+		summary.Reviewed++
+		fmt.Fprintf(s.Out, "Next review of %q in %d day(s).\n", card.Word.Lemma, review.Interval)
+	}
+	if err := input.Err(); err != nil {
+		return summary, err
+	}
+
+	fmt.Fprintf(s.Out, "\nReviewed %d, correct %d.\n", summary.Reviewed, summary.Correct)
+	return summary, nil
+}
+
+func (s *Session) show(question model.Question) {
+	if question.QuestionType == "T" {
+		fmt.Fprintln(s.Out, question.QuestionContext)
+		fmt.Fprintln(s.Out, "Spell the word:")
+		return
+	}
+
+	if question.QuestionContext != "" {
+		fmt.Fprintln(s.Out, question.QuestionContext)
+	}
+	if question.Question != "" {
+		fmt.Fprintln(s.Out, question.Question)
+	}
+	for i, choice := range question.Choices {
+		fmt.Fprintf(s.Out, "  %d) %s\n", i+1, choice.Value)
+	}
+}
+
+func (s *Session) explain(explanation *model.Explanation) {
+	if explanation == nil {
+		return
+	}
+	if explanation.Definition != "" {
+		fmt.Fprintln(s.Out, "Definition:", explanation.Definition)
+	}
+	if explanation.Explanation != "" {
+		fmt.Fprintln(s.Out, explanation.Explanation)
+	}
+	if explanation.Mnemonic != "" {
+		fmt.Fprintln(s.Out, "Mnemonic:", explanation.Mnemonic)
+	}
+}
+
+// Multiple choice accepts the choice number or its text.
+func (s *Session) grade(question model.Question, reply string) bool {
+	if question.QuestionType == "T" {
+		return strings.EqualFold(reply, question.Answer)
+	}
+
+	correct, ok := correctChoice(question)
+	if !ok {
+		return false
+	}
+	if n, err := strconv.Atoi(reply); err == nil && n >= 1 && n <= len(question.Choices) {
+		return question.Choices[n-1] == correct
+	}
+	return strings.EqualFold(reply, correct.Value)
+}
@@ -0,0 +1,194 @@
+package study
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rodatboat/go-vocab/db"
+	"github.com/rodatboat/go-vocab/model"
+)
+
+func seedStore(t *testing.T) db.QuestionStore {
+	t.Helper()
+	store := db.NewMemoryStore()
+	questions := []model.Question{
+		{
+			QuestionType: "S",
+			Question:     "endowment has the same or almost the same meaning as:",
+			Choices: []model.QuestionChoices{
+				{Key: "a1", Value: "talent"},
+				{Key: "b2", Value: "debt"},
+			},
+			Answer:     "talent",
+			AnswerKey:  "a1",
+			IsCorrect:  true,
+			TargetWord: "endowment",
+		},
+		{
+			QuestionType:    "T",
+			QuestionContext: "She was ______ by the news.",
+			Question:        "Spell the word:",
+			Answer:          "stupefied",
+			AnswerKey:       "stupefied",
+			TargetWord:      "stupefied",
+		},
+		// Never answered correctly, so it cannot be studied.
+		{
+			QuestionType: "D",
+			Question:     "to surmise is to:",
+			Choices:      []model.QuestionChoices{{Key: "c3", Value: "guess"}},
+			TargetWord:   "surmise",
+		},
+	}
+	for _, question := range questions {
+		if _, err := store.SaveQuestion(context.Background(), question); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return store
+}
+
+func TestSessionRun(t *testing.T) {
+	ctx := context.Background()
+	store := seedStore(t)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	spelling, err := store.FindQuestion(ctx, "T", "She was ______ by the news.", "Spell the word:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.SaveExplanation(ctx, model.Explanation{
+		QuestionID: spelling.ID,
+		Definition: "so surprised you cannot think",
+		Mnemonic:   "stupor + fied",
+		CreatedAt:  now,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	session := Session{
+		Store: store,
+		In:    strings.NewReader("1\nstupified\n"),
+		Out:   &out,
+		Now:   func() time.Time { return now },
+	}
+	summary, err := session.Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Reviewed != 2 || summary.Correct != 1 {
+		t.Errorf("unexpected summary %+v, output:\n%s", summary, out.String())
+	}
+	if !strings.Contains(out.String(), "the answer is: stupefied\nDefinition: so surprised you cannot think\nMnemonic: stupor + fied") {
+		t.Errorf("wrong spelling not corrected and explained, output:\n%s", out.String())
+	}
+
+	reviews, err := store.ListReviews(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reviews) != 2 {
+		t.Fatalf("want 2 reviews saved, got %+v", reviews)
+	}
+	for _, review := range reviews {
+		if review.Interval != 1 || !review.DueAt.Equal(now.AddDate(0, 0, 1)) {
+			t.Errorf("unexpected review %+v", review)
+		}
+	}
+
+	// Nothing is due until tomorrow.
+	cards, err := DueCards(ctx, store, now.Add(time.Hour), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cards) != 0 {
+		t.Errorf("want no due cards, got %d", len(cards))
+	}
+	cards, err = DueCards(ctx, store, now.AddDate(0, 0, 1), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cards) != 1 || cards[0].IsNew {
+		t.Errorf("unexpected due cards: %+v", cards)
+	}
+}
+
+func TestSessionQuit(t *testing.T) {
+	store := seedStore(t)
+	var out bytes.Buffer
+	session := Session{Store: store, In: strings.NewReader("q\n"), Out: &out}
+	summary, err := session.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Reviewed != 0 {
+		t.Errorf("reviewed %d after quitting", summary.Reviewed)
+	}
+	reviews, _ := store.ListReviews(context.Background())
+	if len(reviews) != 0 {
+		t.Errorf("reviews saved after quitting: %+v", reviews)
+	}
+}
+
+func TestDueCardsHardestNewFirst(t *testing.T) {
+	ctx := context.Background()
+	store := db.NewMemoryStore()
+	for _, word := range []struct {
+		lemma          string
+		correct, total int
+	}{
+		{"abate", 0, 0},
+		{"bolster", 90, 100},
+		{"cajole", 40, 100},
+	} {
+		question := model.Question{
+			QuestionType: "T",
+			Question:     "Spell the word: " + word.lemma,
+			Answer:       word.lemma,
+			TargetWord:   word.lemma,
+			AnswerStats:  model.AnswerStats{Correct: word.correct, Total: word.total},
+		}
+		if word.total > 0 {
+			question.CorrectRate = float64(word.correct) / float64(word.total)
+		}
+		if _, err := store.SaveQuestion(ctx, question); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cards, err := DueCards(ctx, store, time.Now(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var order []string
+	for _, card := range cards {
+		order = append(order, card.Word.Lemma)
+	}
+	if strings.Join(order, ",") != "cajole,bolster,abate" {
+		t.Errorf("new cards in order %v", order)
+	}
+}
+
+// Choices saved with other keys than the answer's are matched by its text.
+func TestGradeFallsBackToAnswer(t *testing.T) {
+	question := model.Question{
+		QuestionType: "S",
+		Choices:      []model.QuestionChoices{{Key: "x9", Value: "debt"}, {Key: "y8", Value: "Talent"}},
+		Answer:       "talent",
+		AnswerKey:    "a1",
+		IsCorrect:    true,
+	}
+	if !hasKnownAnswer(question) {
+		t.Fatal("want the answer found by its text")
+	}
+	s := &Session{}
+	for reply, want := range map[string]bool{"2": true, "talent": true, "1": false, "debt": false} {
+		if got := s.grade(question, reply); got != want {
+			t.Errorf("grade(%q) = %v, want %v", reply, got, want)
+		}
+	}
+}
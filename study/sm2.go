@@ -0,0 +1,67 @@
+package study
+
+import (
+	"math"
+	"time"
+
+	"github.com/rodatboat/go-vocab/model"
+)
+
+const (
+	initialEase = 2.5
+	minimumEase = 1.3
+)
+
+// Answer quality on the SM-2 scale of 0 (blackout) to 5 (perfect). Study
+// mode grades automatically, so only these two are used.
+const (
+	QualityWrong   = 1
+	QualityCorrect = 4
+)
+
+// NewReview is the state of a word that has never been studied, due now.
+func NewReview(wordId int, now time.Time) model.Review {
+	return model.Review{
+		WordID: wordId,
+		Ease:   initialEase,
+		DueAt:  now,
+	}
+}
+
+// Schedule applies one SM-2 step. A quality below 3 restarts the word at a
+// one day interval, anything else grows the interval by the ease factor.
+func Schedule(review model.Review, quality int, now time.Time) model.Review {
+	if quality < 0 {
+		quality = 0
+	} else if quality > 5 {
+		quality = 5
+	}
+	if review.Ease == 0 {
+		review.Ease = initialEase
+	}
+
+	if quality >= 3 {
+		switch review.Repetitions {
+		case 0:
+			review.Interval = 1
+		case 1:
+			review.Interval = 6
+		default:
+			review.Interval = int(math.Round(float64(review.Interval) * review.Ease))
+		}
+		review.Repetitions++
+	} else {
+		review.Repetitions = 0
+		review.Interval = 1
+	}
+
+	miss := float64(5 - quality)
+	review.Ease += 0.1 - miss*(0.08+miss*0.02)
+	if review.Ease < minimumEase {
+		review.Ease = minimumEase
+	}
+
+	review.LastReviewedAt = now
+	review.DueAt = now.AddDate(0, 0, review.Interval)
+	return review
+}
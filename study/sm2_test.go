@@ -0,0 +1,44 @@
+package study
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSchedule(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	review := NewReview(7, now)
+
+	var intervals []int
+	for i := 0; i < 4; i++ {
+		review = Schedule(review, QualityCorrect, now)
+		intervals = append(intervals, review.Interval)
+	}
+	want := []int{1, 6, 15, 38}
+	for i := range want {
+		if intervals[i] != want[i] {
+			t.Fatalf("intervals = %v, want %v", intervals, want)
+		}
+	}
+	if review.Repetitions != 4 || review.Ease != initialEase {
+		t.Errorf("unexpected review after correct answers: %+v", review)
+	}
+
+	review = Schedule(review, QualityWrong, now)
+	if review.Interval != 1 || review.Repetitions != 0 {
+		t.Errorf("wrong answer did not reset the word: %+v", review)
+	}
+	if review.Ease >= initialEase {
+		t.Errorf("wrong answer did not lower ease: %v", review.Ease)
+	}
+	if !review.DueAt.Equal(now.AddDate(0, 0, 1)) || !review.LastReviewedAt.Equal(now) {
+		t.Errorf("unexpected dates: %+v", review)
+	}
+
+	for i := 0; i < 10; i++ {
+		review = Schedule(review, QualityWrong, now)
+	}
+	if review.Ease != minimumEase {
+		t.Errorf("ease = %v, want floor %v", review.Ease, minimumEase)
+	}
+}
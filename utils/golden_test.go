@@ -0,0 +1,219 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/rodatboat/go-vocab/model"
+)
+
+var update = flag.Bool("update", false, "regenerate golden files in testdata/golden")
+
+const exampleDir = "../example"
+const goldenDir = "testdata/golden"
+
+// goldenResult is what gets checked in for every fixture. Code and
+// DecodedCode are cleared since they are just the fixture itself.
+type goldenResult struct {
+	Question interface{} `json:"question,omitempty"`
+	Error    string      `json:"error,omitempty"`
+}
+
+// Fixtures that cannot be parsed, by the field their ParseError names. They
+// are checked for that error instead of against a golden file.
+var expectedErrors = map[string]string{
+	// Captured with a stray "+" in front of the code, so it is not base64.
+	"example.start2": "question.code",
+}
+
+type fixture struct {
+	name string
+	body []byte
+}
+
+// Loads the API envelopes as-is, and wraps each challenge type slide in a
+// minimal envelope using the type letter from its file name.
+func loadFixtures(t *testing.T) []fixture {
+	t.Helper()
+	var fixtures []fixture
+
+	envelopes, err := filepath.Glob(filepath.Join(exampleDir, "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range envelopes {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fixtures = append(fixtures, fixture{
+			name: strings.TrimSuffix(filepath.Base(path), ".json"),
+			body: raw,
+		})
+	}
+
+	slides, err := filepath.Glob(filepath.Join(exampleDir, "challenge types", "*.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range slides {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		base := strings.TrimSuffix(filepath.Base(path), ".html")
+		questionType := strings.SplitN(base, "-", 2)[0]
+		body, err := json.Marshal(model.ChallengeResponse{
+			Secret: "fixture-secret",
+			Question: &model.ChallengeQuestion{
+				Type: questionType,
+				Code: base64.StdEncoding.EncodeToString(raw),
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		fixtures = append(fixtures, fixture{
+			name: "slide." + strings.ReplaceAll(base, " ", "_"),
+			body: body,
+		})
+	}
+
+	if len(fixtures) == 0 {
+		t.Fatal("no fixtures found in", exampleDir)
+	}
+	return fixtures
+}
+
+// A panic fails the test, it is never written to a golden file.
+func parseFixture(t *testing.T, f fixture) (*model.Question, error) {
+	t.Helper()
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("fixture %s: parser panicked: %v", f.name, r)
+		}
+	}()
+
+	data, err := DecodeChallengeResponse(f.body)
+	if err != nil {
+		return nil, err
+	}
+	question, _, err := ExtractQuestion(data)
+	if err != nil {
+		return nil, err
+	}
+	question.Code = ""
+	question.DecodedCode = ""
+	return question, nil
+}
+
+func TestGoldenFixtures(t *testing.T) {
+	for _, f := range loadFixtures(t) {
+		t.Run(f.name, func(t *testing.T) {
+			question, err := parseFixture(t, f)
+			if field, ok := expectedErrors[f.name]; ok {
+				var parseErr *ParseError
+				if !errors.As(err, &parseErr) || parseErr.Field != field {
+					t.Fatalf("fixture %s: want a ParseError on %s, got %v", f.name, field, err)
+				}
+				return
+			}
+			got := goldenResult{Question: question}
+			if err != nil {
+				got = goldenResult{Error: err.Error()}
+			}
+			gotJson, err := json.MarshalIndent(got, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			gotJson = append(gotJson, '\n')
+
+			goldenPath := filepath.Join(goldenDir, f.name+".golden.json")
+			if *update {
+				if err := os.MkdirAll(goldenDir, 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(goldenPath, gotJson, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			wantJson, err := os.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatalf("missing golden file %s, run `go test ./utils -update`: %v", goldenPath, err)
+			}
+
+			var want, have interface{}
+			if err := json.Unmarshal(wantJson, &want); err != nil {
+				t.Fatalf("%s: %v", goldenPath, err)
+			}
+			if err := json.Unmarshal(gotJson, &have); err != nil {
+				t.Fatal(err)
+			}
+			for _, diff := range diffJson("", want, have) {
+				t.Errorf("fixture %s: %s", f.name, diff)
+			}
+		})
+	}
+}
+
+// Walks both decoded JSON values and reports every differing field path.
+func diffJson(path string, want, got interface{}) []string {
+	switch w := want.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: want object, got %v", fieldName(path), got)}
+		}
+		keys := map[string]bool{}
+		for k := range w {
+			keys[k] = true
+		}
+		for k := range g {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+
+		var diffs []string
+		for _, k := range sorted {
+			diffs = append(diffs, diffJson(path+"."+k, w[k], g[k])...)
+		}
+		return diffs
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok || len(g) != len(w) {
+			return []string{fmt.Sprintf("%s: want %v, got %v", fieldName(path), want, got)}
+		}
+		var diffs []string
+		for i := range w {
+			diffs = append(diffs, diffJson(fmt.Sprintf("%s[%d]", path, i), w[i], g[i])...)
+		}
+		return diffs
+	default:
+		if !reflect.DeepEqual(want, got) {
+			return []string{fmt.Sprintf("%s: want %v, got %v", fieldName(path), want, got)}
+		}
+		return nil
+	}
+}
+
+func fieldName(path string) string {
+	if path == "" {
+		return "<root>"
+	}
+	return strings.TrimPrefix(path, ".")
+}
@@ -0,0 +1,236 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/rodatboat/go-vocab/model"
+)
+
+// QuestionParser fills a question from the decoded slide HTML of a single
+// challenge type. Implementations are registered by the `type`/`qtype` code.
+type QuestionParser interface {
+	Parse(doc *goquery.Document, question *model.Question) error
+}
+
+// UnsupportedQuestionTypeError is returned when no parser is registered for
+// a question type. HTML holds the decoded slide so it can be saved and
+// inspected later.
+type UnsupportedQuestionTypeError struct {
+	QuestionType string
+	HTML         string
+}
+
+func (e *UnsupportedQuestionTypeError) Error() string {
+	return fmt.Sprintf("unsupported question type %q", e.QuestionType)
+}
+
+var questionParsers = map[string]QuestionParser{
+	"A": oppositeParser{},
+	"D": definitionParser{},
+	"F": excerptParser{},
+	"H": sentenceSynonymParser{},
+	"I": imageParser{},
+	"L": quoteParser{},
+	"P": usageParser{},
+	"S": similarMeaningParser{},
+	"T": spellingParser{},
+}
+
+// RegisterQuestionParser adds or replaces the parser used for questionType.
+func RegisterQuestionParser(questionType string, parser QuestionParser) {
+	questionParsers[questionType] = parser
+}
+
+func GetQuestionParser(questionType string) (QuestionParser, bool) {
+	parser, ok := questionParsers[questionType]
+	return parser, ok
+}
+
+// A-type: "The opposite of <word> is:", no context sentence.
+type oppositeParser struct{}
+
+func (oppositeParser) Parse(doc *goquery.Document, question *model.Question) error {
+	return parseInstructionOnly(doc, question)
+}
+
+// D-type: "<word> means:", choices are definitions.
+type definitionParser struct{}
+
+func (definitionParser) Parse(doc *goquery.Document, question *model.Question) error {
+	return parseInstructionOnly(doc, question)
+}
+
+// S-type: "<word> has the same or almost the same meaning as:".
+type similarMeaningParser struct{}
+
+func (similarMeaningParser) Parse(doc *goquery.Document, question *model.Question) error {
+	return parseInstructionOnly(doc, question)
+}
+
+// F-type: an excerpt with the word blanked out and empty instructions.
+type excerptParser struct{}
+
+func (excerptParser) Parse(doc *goquery.Document, question *model.Question) error {
+	return parseSentence(doc, question)
+}
+
+// H-type: "In the sentence above, <word> has the same ... meaning as:".
+type sentenceSynonymParser struct{}
+
+func (sentenceSynonymParser) Parse(doc *goquery.Document, question *model.Question) error {
+	return parseSentence(doc, question)
+}
+
+// L-type: a quoted sentence followed by "In this sentence, <word> means:".
+type quoteParser struct{}
+
+func (quoteParser) Parse(doc *goquery.Document, question *model.Question) error {
+	return parseSentence(doc, question)
+}
+
+// P-type: the sentence itself is the question, instructions are empty.
+type usageParser struct{}
+
+func (usageParser) Parse(doc *goquery.Document, question *model.Question) error {
+	return parseSentence(doc, question)
+}
+
+// I-type: pick the picture for a word, choices carry no text.
+type imageParser struct{}
+
+func (imageParser) Parse(doc *goquery.Document, question *model.Question) error {
+	wrapper := doc.Find("div.word div.wrapper").First()
+	instructions := stripExtraWhiteSpace(wrapper.Find("div.instructions").Text())
+	word := stripExtraWhiteSpace(wrapper.Clone().Children().Remove().End().Text())
+	question.Question = strings.TrimSpace(instructions + " " + word)
+	question.TargetWord = word
+
+	var choices []model.QuestionChoices
+	doc.Find("div.choices a").Each(func(i int, s *goquery.Selection) {
+		keyVal, ok := s.Attr("data-nonce")
+		if !ok {
+			return
+		}
+		style, _ := s.Attr("style")
+		choices = append(choices, model.QuestionChoices{
+			Key:      keyVal,
+			ImageURL: backgroundImageURL(style),
+		})
+	})
+	if len(choices) == 0 {
+		return errors.New("no image choices found")
+	}
+	question.Choices = choices
+	return nil
+}
+
+var backgroundImagePattern = regexp.MustCompile(`background-image:\s*url\(\s*['"]?([^'")]+?)['"]?\s*\)`)
+
+// The picture of an image choice is only set in its inline style.
+func backgroundImageURL(style string) string {
+	match := backgroundImagePattern.FindStringSubmatch(style)
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+// T-type: spell the word, the answer is in the completed sentence.
+type spellingParser struct{}
+
+func (spellingParser) Parse(doc *goquery.Document, question *model.Question) error {
+	question.QuestionContext = stripExtraWhiteSpace(doc.Find("div.sentence.blanked").First().Text())
+	question.Question = stripExtraWhiteSpace(doc.Find("div.spelltheword div.label").First().Text())
+
+	answer := stripExtraWhiteSpace(doc.Find("div.sentence.complete strong").First().Text())
+	if answer == "" {
+		return errors.New("no spelling answer found")
+	}
+	question.Answer = answer
+	question.AnswerKey = answer
+	question.TargetWord = answer
+	return nil
+}
+
+func parseInstructionOnly(doc *goquery.Document, question *model.Question) error {
+	question.Question = stripExtraWhiteSpace(doc.Find("div.instructions").First().Text())
+	question.TargetWord = firstStrongWord(doc.Find("div.instructions"))
+	return parseTextChoices(doc, question)
+}
+
+func parseSentence(doc *goquery.Document, question *model.Question) error {
+	context := doc.Find("div.questionContent").First().Find("div.sentence")
+	if context.Length() == 0 {
+		return errors.New("no context sentence found")
+	}
+	question.QuestionContext = stripExtraWhiteSpace(context.Text())
+	question.Question = stripExtraWhiteSpace(doc.Find("div.instructions").First().Text())
+	question.TargetWord = firstStrongWord(doc.Find("div.instructions, div.questionContent div.sentence"))
+	return parseTextChoices(doc, question)
+}
+
+// The word being asked about is the first <strong> that isn't a blank like
+// "________". Excerpt slides only have the blank, so they get no word until
+// the answer comes back.
+func firstStrongWord(selection *goquery.Selection) string {
+	word := ""
+	selection.Find("strong").EachWithBreak(func(i int, s *goquery.Selection) bool {
+		text := stripExtraWhiteSpace(s.Text())
+		if strings.Trim(text, "_") == "" {
+			return true
+		}
+		word = text
+		return false
+	})
+	return word
+}
+
+func parseTextChoices(doc *goquery.Document, question *model.Question) error {
+	var choices []model.QuestionChoices
+	doc.Find("div.choices a").Each(func(i int, s *goquery.Selection) {
+		keyVal, ok := s.Attr("data-nonce")
+		if !ok {
+			slog.Warn("choice without data-nonce, skipping", "question_type", question.QuestionType)
+			return
+		}
+		choices = append(choices, model.QuestionChoices{
+			Key:   keyVal,
+			Value: stripExtraWhiteSpace(s.Text()),
+		})
+	})
+	if len(choices) == 0 {
+		return errors.New("no choices found")
+	}
+	question.Choices = choices
+	return nil
+}
+
+// Metadata shared by every slide type. All of it is optional.
+func parseSlideMetadata(doc *goquery.Document, question *model.Question) {
+	slide := doc.Find("div.challenge-slide").First()
+	question.SlideType, _ = slide.Attr("data-slide-type")
+	question.AudioID, _ = slide.Attr("data-audio")
+	question.Template, _ = doc.Find("[data-template]").First().Attr("data-template")
+
+	mode := doc.Find("div.mode").First()
+	if class, ok := mode.Find("span").Not(".pointValue").First().Attr("class"); ok {
+		question.Mode = strings.ToLower(strings.TrimSpace(class))
+	}
+	if points, err := strconv.Atoi(strings.TrimSpace(mode.Find("span.pointValue").Text())); err == nil {
+		question.PointValue = points
+	}
+
+	source := doc.Find("a.source").First()
+	question.SourceURL, _ = source.Attr("href")
+	if title, ok := source.Attr("title"); ok {
+		question.SourceTitle = stripExtraWhiteSpace(title)
+	} else {
+		question.SourceTitle = strings.TrimPrefix(stripExtraWhiteSpace(source.Text()), "Source: ")
+	}
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rodatboat/go-vocab/model"
+)
+
+// ParseError reports the response or slide field that was missing or had
+// an unexpected type. Field is a dotted path such as "answer.correct".
+type ParseError struct {
+	Field string
+	Err   error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("field %q: %v", e.Field, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+var errFieldMissing = errors.New("missing")
+
+// ErrSecretMissing is wrapped in a ParseError when a response has no secret.
+var ErrSecretMissing = errors.New("secret missing")
+
+// DecodeChallengeResponse decodes a challenge endpoint body. Each of the
+// required dotted paths must be present, unless the body is an error
+// response, which is returned as-is so the caller can inspect Error.
+func DecodeChallengeResponse(body []byte, required ...string) (*model.ChallengeResponse, error) {
+	var resp model.ChallengeResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return nil, &ParseError{
+				Field: typeErr.Field,
+				Err:   fmt.Errorf("expected %s, got JSON %s", typeErr.Type, typeErr.Value),
+			}
+		}
+		return nil, fmt.Errorf("decoding challenge response: %w", err)
+	}
+	if resp.Error != "" || len(required) == 0 {
+		return &resp, nil
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("decoding challenge response: %w", err)
+	}
+	for _, field := range required {
+		if !hasField(raw, field) {
+			if field == "secret" {
+				return nil, &ParseError{Field: field, Err: ErrSecretMissing}
+			}
+			return nil, &ParseError{Field: field, Err: errFieldMissing}
+		}
+	}
+	return &resp, nil
+}
+
+func hasField(data map[string]interface{}, path string) bool {
+	parts := strings.Split(path, ".")
+	for i, part := range parts {
+		value, ok := data[part]
+		if !ok || value == nil {
+			return false
+		}
+		if i == len(parts)-1 {
+			return true
+		}
+		data, ok = value.(map[string]interface{})
+		if !ok {
+			return false
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/rodatboat/go-vocab/model"
+)
+
+func TestDecodeChallengeResponseFieldErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		required []string
+		field    string
+	}{
+		{"mistyped points", `{"secret":"s","answer":{"points":"ten"}}`, nil, "answer.points"},
+		{"mistyped difficulty", `{"secret":"s","question":{"difficulty":"hard"}}`, nil, "question.difficulty"},
+		{"missing secret", `{"game":{"progress":0.5}}`, []string{"secret"}, "secret"},
+		{"missing nested", `{"secret":"s","answer":{"word":"w"}}`, []string{"secret", "answer.correct"}, "answer.correct"},
+		{"null nested", `{"secret":"s","game":null}`, []string{"game.progress"}, "game.progress"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeChallengeResponse([]byte(tt.body), tt.required...)
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("want *ParseError, got %v", err)
+			}
+			if parseErr.Field != tt.field {
+				t.Errorf("want field %q, got %q", tt.field, parseErr.Field)
+			}
+		})
+	}
+}
+
+func TestDecodeChallengeResponseErrorBody(t *testing.T) {
+	resp, err := DecodeChallengeResponse([]byte(`{"error":"RestartChallengeException"}`), "secret", "question")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Error != "RestartChallengeException" {
+		t.Errorf("want RestartChallengeException, got %q", resp.Error)
+	}
+}
+
+func TestExtractAccountSnapshot(t *testing.T) {
+	body, err := os.ReadFile("../example/example.start.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := DecodeChallengeResponse(body, "pdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := ExtractAccountSnapshot(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.Points != 12795855 || snapshot.Level.Name != "Walking Dictionary" || snapshot.NumMastered != 4854 {
+		t.Errorf("unexpected snapshot: %+v", snapshot)
+	}
+	if len(snapshot.Lists) != 2 || snapshot.Lists[1].Name != "Cat Vocabulary: A Feline Lexicon" || !snapshot.Lists[1].Completed {
+		t.Errorf("unexpected lists: %+v", snapshot.Lists)
+	}
+
+	var parseErr *ParseError
+	if _, err := ExtractAccountSnapshot(&model.ChallengeResponse{}); !errors.As(err, &parseErr) || parseErr.Field != "pdata" {
+		t.Errorf("want pdata ParseError, got %v", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"math"
 	"math/rand"
 	"net/http"
@@ -22,79 +23,67 @@ func GetCookiesString(cookies []cycletls.Cookie) (string, error) {
 		for _, cookie := range cookies {
 			cookieHeader += cookie.Name + "=" + cookie.Value + ";"
 		}
-		fmt.Println("Cookie header:", cookieHeader)
 		return cookieHeader, nil
 	}
 	return "", errors.New("no cookies found")
 }
 
-func ExtractQuestion(data map[string]interface{}) (*model.Question, string, error) {
+func ExtractQuestion(data *model.ChallengeResponse) (*model.Question, string, error) {
 	question := model.Question{}
 	secret, err := ExtractSecret(data)
 	if err != nil {
-		fmt.Println("Error extracting secret:", err)
-		panic(err)
+		return nil, "", err
 	}
 
-	questionData, ok := data["question"].(map[string]interface{})
-	if !ok {
-		fmt.Println("Error getting question data, trying base data JSON instead...")
-		questionData = data
-		question.QuestionType = questionData["qtype"].(string)
+	if data.Question != nil {
+		question.QuestionType = data.Question.Type
+		question.Code = data.Question.Code
+		question.Difficulty = data.Question.Difficulty
+		question.Turn = data.Question.Turn
+		question.Category = data.Question.Category
+		question.AnswerStats = data.Question.AnswerStats
+		if question.AnswerStats.Total > 0 {
+			question.CorrectRate = float64(question.AnswerStats.Correct) / float64(question.AnswerStats.Total)
+		}
 	} else {
-		question.QuestionType = questionData["type"].(string)
+		slog.Debug("no question object, using the top level fields", "question_type", data.QType)
+		question.QuestionType = data.QType
+		question.Code = data.Code
+		question.Difficulty = data.Difficulty
+	}
+	if question.QuestionType == "" {
+		return nil, "", &ParseError{Field: "question.type", Err: errFieldMissing}
+	}
+	if question.Code == "" {
+		return nil, "", &ParseError{Field: "question.code", Err: errFieldMissing}
 	}
 
 	question.IsCorrect = false
-	question.Code = questionData["code"].(string)
-	question.Difficulty = questionData["difficulty"].(float64)
 
 	decodedQuestion, err := base64.StdEncoding.DecodeString(question.Code)
 	if err != nil {
-		fmt.Println("Error base64 decoding question:", err)
-		return nil, "", err
+		return nil, "", &ParseError{Field: "question.code", Err: err}
 	}
 	question.DecodedCode = string(decodedQuestion)
 
+	parser, ok := GetQuestionParser(question.QuestionType)
+	if !ok {
+		return nil, "", &UnsupportedQuestionTypeError{
+			QuestionType: question.QuestionType,
+			HTML:         question.DecodedCode,
+		}
+	}
+
 	// Create HTML doc using a string reader
 	doc, err := goquery.NewDocumentFromReader(strings.NewReader(question.DecodedCode))
 	if err != nil {
-		fmt.Println("Error creating HTML doc:", err)
 		return nil, "", err
 	}
 
-	questionContext := doc.Find("div.questionContent").First()
-	contextParts := questionContext.Find("div.sentence")
-	questionContent := doc.Find("div.instructions").First()
-
-	question.QuestionContext = stripExtraWhiteSpace(contextParts.Text())
-	question.Question = stripExtraWhiteSpace(questionContent.Text())
-
-	if question.QuestionType == "T" {
-		spellingQuestionAnswer := doc.Find("div.complete strong").First().Text()
-		question.Answer = spellingQuestionAnswer
-		question.AnswerKey = spellingQuestionAnswer
+	if err := parser.Parse(doc, &question); err != nil {
+		return nil, "", fmt.Errorf("parse %s-type question: %w", question.QuestionType, err)
 	}
-
-	var choices []model.QuestionChoices
-	doc.Find("div.choices a").Each(func(i int, s *goquery.Selection) {
-		keyVal, ok := s.Attr("data-nonce")
-		if !ok {
-			fmt.Println("Error getting data-nonce")
-			return
-		}
-		val := stripExtraWhiteSpace(s.Text())
-		if val == question.Answer {
-			question.IsCorrect = true
-			question.AnswerKey = keyVal
-		}
-
-		choices = append(choices, model.QuestionChoices{
-			Key:   keyVal,
-			Value: val,
-		})
-	})
-	question.Choices = choices
+	parseSlideMetadata(doc, &question)
 
 	return &question, secret, nil
 }
@@ -117,29 +106,65 @@ func PrettyPrint(v interface{}) {
 	fmt.Println(string(jsonBytes))
 }
 
-func ExtractSecret(data map[string]interface{}) (string, error) {
-	secret, ok := data["secret"].(string)
-	if !ok {
-		err := errors.New("error decoding secret")
-		return "", err
+func ExtractSecret(data *model.ChallengeResponse) (string, error) {
+	if data.Secret == "" {
+		return "", &ParseError{Field: "secret", Err: ErrSecretMissing}
 	}
+	return data.Secret, nil
+}
 
-	// Store in progress, then load on startup
-	return secret, nil
+func ExtractPracticeProgress(data *model.ChallengeResponse) (*float64, error) {
+	if data.Game == nil {
+		return nil, &ParseError{Field: "game", Err: errFieldMissing}
+	}
+	progress := data.Game.Progress
+	return &progress, nil
 }
 
-func ExtractPracticeProgress(data map[string]interface{}) (*float64, error) {
-	gameJson, ok := data["game"].(map[string]interface{})
-	if !ok {
-		err := errors.New("failed to decode game JSON")
-		return nil, err
+// ExtractAccountSnapshot reads the user's level and word lists from pdata.
+func ExtractAccountSnapshot(data *model.ChallengeResponse) (*model.AccountSnapshot, error) {
+	if data.PData == nil {
+		return nil, &ParseError{Field: "pdata", Err: errFieldMissing}
 	}
-	progress, ok := gameJson["progress"].(float64)
-	if !ok {
-		err := errors.New("failed to decode progress JSON")
-		return nil, err
+	snapshot := &model.AccountSnapshot{
+		Points:      data.PData.Points,
+		Level:       data.PData.Level,
+		NumPlayed:   data.PData.NumPlayed,
+		NumMastered: data.PData.NumMastered,
 	}
-	return &progress, nil
+	for _, list := range data.PData.Lists {
+		snapshot.Lists = append(snapshot.Lists, model.WordList{
+			ID:                   list.ListId,
+			Name:                 list.Name,
+			WordCount:            list.WordCount,
+			CompletionPercentage: list.Progress,
+			Completed:            list.Progress >= 1,
+			Priority:             list.Priority,
+			Current:              list.Current,
+		})
+	}
+	return snapshot, nil
+}
+
+// ExtractProgressSnapshot reads the game state, plus the mastered and played
+// counts from pdata when the response has them.
+func ExtractProgressSnapshot(data *model.ChallengeResponse) (*model.ProgressSnapshot, error) {
+	if data.Game == nil {
+		return nil, &ParseError{Field: "game", Err: errFieldMissing}
+	}
+	snapshot := &model.ProgressSnapshot{
+		ListId:   data.Game.WordListId,
+		ListName: data.Game.Name,
+		Progress: data.Game.Progress,
+		Played:   data.Game.Played,
+		Correct:  data.Game.Correct,
+		Points:   data.Game.Points,
+	}
+	if data.PData != nil {
+		snapshot.NumPlayed = data.PData.NumPlayed
+		snapshot.NumMastered = data.PData.NumMastered
+	}
+	return snapshot, nil
 }
 
 func GenerateRandomTime() int {